@@ -0,0 +1,166 @@
+package categorizer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"csv_extractor/models"
+)
+
+// amountBuckets split absolute amounts, in cents, into coarse ranges so the
+// amount counts as one more token.
+var amountBuckets = []int64{1000, 5000, 10000, 25000, 50000, 100000, 500000}
+
+// Prediction is the classifier's best category for a title. Confidence is its
+// posterior probability, between 0 and 1.
+type Prediction struct {
+	CategoryId int
+	Category   string
+	Confidence float64
+}
+
+type classStats struct {
+	name   string
+	docs   int
+	tokens int
+	counts map[string]int
+}
+
+// Classifier is a multinomial naive Bayes model over title tokens and amount
+// buckets, trained on expenses whose category is known.
+type Classifier struct {
+	classes  map[int]*classStats
+	vocab    map[string]bool
+	examples int
+}
+
+// Tokens splits a title into lower-case words, leaving out single letters
+// and bare numbers, which are mostly dates and ids.
+func Tokens(title string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+
+	for _, f := range fields {
+		if len([]rune(f)) < 2 || strings.IndexFunc(f, unicode.IsLetter) < 0 {
+			continue
+		}
+
+		tokens = append(tokens, f)
+	}
+
+	return tokens
+}
+
+func amountToken(amount models.Money) string {
+	cents := amount.Abs().Cents
+	bucket := len(amountBuckets)
+
+	for i, limit := range amountBuckets {
+		if cents < limit {
+			bucket = i
+			break
+		}
+	}
+
+	return "amount:" + string(rune('0'+bucket))
+}
+
+func features(title string, amount models.Money) []string {
+	return append(Tokens(title), amountToken(amount))
+}
+
+// Train builds a classifier from categorized expenses, reading each one's
+// Value as its typical amount.
+func Train(expenses []models.Expense) *Classifier {
+	c := &Classifier{
+		classes: make(map[int]*classStats),
+		vocab:   make(map[string]bool),
+	}
+
+	for _, e := range expenses {
+		if e.CategoryId == 0 {
+			continue
+		}
+
+		s, ok := c.classes[e.CategoryId]
+
+		if !ok {
+			s = &classStats{name: e.Category, counts: make(map[string]int)}
+			c.classes[e.CategoryId] = s
+		}
+
+		s.docs++
+		c.examples++
+
+		for _, f := range features(e.Title, e.Value) {
+			s.counts[f]++
+			s.tokens++
+			c.vocab[f] = true
+		}
+	}
+
+	return c
+}
+
+// Examples is how many expenses the classifier learned from.
+func (c *Classifier) Examples() int {
+	return c.examples
+}
+
+// Predict returns the most likely category. ok is false when the classifier
+// knows no category or none of the title's words.
+func (c *Classifier) Predict(title string, amount models.Money) (p Prediction, ok bool) {
+	if len(c.classes) == 0 {
+		return p, false
+	}
+
+	var known []string
+
+	for _, t := range Tokens(title) {
+		if c.vocab[t] {
+			known = append(known, t)
+		}
+	}
+
+	// the amount alone says too little to suggest anything
+	if len(known) == 0 {
+		return p, false
+	}
+
+	known = append(known, amountToken(amount))
+
+	vocab := float64(len(c.vocab))
+	scores := make(map[int]float64, len(c.classes))
+	best, bestScore := 0, math.Inf(-1)
+
+	for id, s := range c.classes {
+		score := math.Log(float64(s.docs) / float64(c.examples))
+
+		for _, t := range known {
+			score += math.Log((float64(s.counts[t]) + 1) / (float64(s.tokens) + vocab))
+		}
+
+		scores[id] = score
+
+		if score > bestScore || (score == bestScore && id < best) {
+			best, bestScore = id, score
+		}
+	}
+
+	// posterior of the best class, normalised against every class
+	var total float64
+
+	for _, score := range scores {
+		total += math.Exp(score - bestScore)
+	}
+
+	return Prediction{
+		CategoryId: best,
+		Category:   c.classes[best].name,
+		Confidence: 1 / total,
+	}, true
+}
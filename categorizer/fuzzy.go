@@ -0,0 +1,110 @@
+package categorizer
+
+import (
+	"strings"
+
+	"csv_extractor/models"
+)
+
+// Match is the existing expense closest to a new title. Score is the share
+// of characters that need no edit, between 0 and 1.
+type Match struct {
+	Title        string
+	ExpenseId    int
+	ExpenseTitle string
+	CategoryId   int
+	Category     string
+	Score        float64
+}
+
+type indexedExpense struct {
+	expense models.Expense
+	runes   []rune
+}
+
+// Index finds existing expenses by edit distance to a title.
+type Index struct {
+	expenses []indexedExpense
+}
+
+func NewIndex(expenses []models.Expense) *Index {
+	idx := &Index{expenses: make([]indexedExpense, 0, len(expenses))}
+
+	for _, e := range expenses {
+		idx.expenses = append(idx.expenses, indexedExpense{expense: e, runes: []rune(strings.ToLower(e.Title))})
+	}
+
+	return idx
+}
+
+// Closest returns the most similar expense scoring at least threshold.
+func (idx *Index) Closest(title string, threshold float64) (Match, bool) {
+	t := []rune(strings.ToLower(title))
+
+	var best Match
+
+	for _, e := range idx.expenses {
+		longest := max(len(t), len(e.runes))
+
+		if longest == 0 {
+			continue
+		}
+
+		// the length difference alone is a lower bound of the distance
+		if 1-float64(abs(len(t)-len(e.runes)))/float64(longest) < threshold {
+			continue
+		}
+
+		score := 1 - float64(levenshtein(t, e.runes))/float64(longest)
+
+		if score >= threshold && score > best.Score {
+			best = Match{
+				Title:        title,
+				ExpenseId:    e.expense.Id,
+				ExpenseTitle: e.expense.Title,
+				CategoryId:   e.expense.CategoryId,
+				Category:     e.expense.Category,
+				Score:        score,
+			}
+		}
+	}
+
+	return best, best.ExpenseId != 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// levenshtein counts the insertions, deletions and substitutions turning a
+// into b, keeping two rows of the table.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,137 @@
+package categorizer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"csv_extractor/models"
+	"csv_extractor/parsers"
+)
+
+type compiledRule struct {
+	models.CategorizationRule
+	regex *regexp.Regexp
+}
+
+// Rules evaluates categorization rules in priority order.
+type Rules struct {
+	rules []compiledRule
+}
+
+func ValidateRule(rule models.CategorizationRule) error {
+	switch rule.MatchType {
+	case "":
+		if rule.Pattern != "" {
+			return errors.New("pattern needs a match type")
+		}
+
+		if rule.MinAmount == nil && rule.MaxAmount == nil {
+			return errors.New("rule needs a title pattern or an amount range")
+		}
+	case models.RuleContains, models.RulePrefix:
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return errors.New("pattern is required")
+		}
+	case models.RuleRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf(`match type must be %q, %q or %q`, models.RuleContains, models.RulePrefix, models.RuleRegex)
+	}
+
+	if rule.MinAmount != nil && rule.MaxAmount != nil && rule.MinAmount.Cents > rule.MaxAmount.Cents {
+		return errors.New("minimum amount is greater than the maximum")
+	}
+
+	if rule.CategoryId == 0 {
+		return errors.New("category is required")
+	}
+
+	return nil
+}
+
+// Compile prepares active rules for matching, skipping inactive ones. Plain
+// patterns are folded like the titles they are matched against, so "UBER *TRIP"
+// matches "UBER*TRIP".
+func Compile(rules []models.CategorizationRule) (*Rules, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for _, r := range rules {
+		if !r.Active {
+			continue
+		}
+
+		c := compiledRule{CategorizationRule: r}
+
+		switch r.MatchType {
+		case models.RuleContains, models.RulePrefix:
+			c.Pattern = parsers.FoldTitle(r.Pattern)
+		case models.RuleRegex:
+			// titles are matched regardless of case, as with the other types
+			re, err := regexp.Compile("(?i)" + r.Pattern)
+
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid regex: %w", r.Id, err)
+			}
+
+			c.regex = re
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].Priority != compiled[j].Priority {
+			return compiled[i].Priority > compiled[j].Priority
+		}
+
+		return compiled[i].Id < compiled[j].Id
+	})
+
+	return &Rules{rules: compiled}, nil
+}
+
+// Match returns the first rule matching the title and amount, or nil.
+func (r *Rules) Match(title string, amount models.Money) *models.CategorizationRule {
+	for i := range r.rules {
+		if r.rules[i].matches(title, amount) {
+			return &r.rules[i].CategorizationRule
+		}
+	}
+
+	return nil
+}
+
+func (c *compiledRule) matches(title string, amount models.Money) bool {
+	lower := strings.ToLower(title)
+	pattern := strings.ToLower(c.Pattern)
+
+	switch c.MatchType {
+	case models.RuleContains:
+		if !strings.Contains(lower, pattern) {
+			return false
+		}
+	case models.RulePrefix:
+		if !strings.HasPrefix(lower, pattern) {
+			return false
+		}
+	case models.RuleRegex:
+		if !c.regex.MatchString(title) {
+			return false
+		}
+	}
+
+	if c.MinAmount != nil && amount.Cents < c.MinAmount.Cents {
+		return false
+	}
+
+	if c.MaxAmount != nil && amount.Cents > c.MaxAmount.Cents {
+		return false
+	}
+
+	return true
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func GetAllCategories(db *sql.DB, isActive bool) ([]models.Category, error) {
-	rows, err := db.Query("SELECT * FROM categories WHERE is_active=$1", isActive)
+	rows, err := db.Query("SELECT id, name, is_active, COALESCE(parent_id, 0) FROM categories WHERE is_active=$1", isActive)
 
 	if err != nil {
 		log.Fatal("Error - database querying: ", err)
@@ -25,7 +25,7 @@ func GetAllCategories(db *sql.DB, isActive bool) ([]models.Category, error) {
 	for rows.Next() {
 		var c models.Category
 
-		err := rows.Scan(&c.Id, &c.Name, &c.Active)
+		err := rows.Scan(&c.Id, &c.Name, &c.Active, &c.ParentId)
 
 		if err != nil {
 			return nil, err
@@ -38,11 +38,11 @@ func GetAllCategories(db *sql.DB, isActive bool) ([]models.Category, error) {
 }
 
 func GetCategoryById(db *sql.DB, categoryId int) (*models.Category, error) {
-	query := "SELECT id, name FROM categories WHERE id = $1"
+	query := "SELECT id, name, is_active, COALESCE(parent_id, 0) FROM categories WHERE id = $1"
 
 	var c models.Category
 
-	err := db.QueryRow(query, categoryId).Scan(&c.Id, &c.Name)
+	err := db.QueryRow(query, categoryId).Scan(&c.Id, &c.Name, &c.Active, &c.ParentId)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -55,7 +55,7 @@ func GetCategoryById(db *sql.DB, categoryId int) (*models.Category, error) {
 	return &c, nil
 }
 
-func GetCategoryByName(db *sql.DB, cName string) (*models.Category, error) {
+func GetCategoryByName(db Querier, cName string) (*models.Category, error) {
 	query := "SELECT id, name FROM categories WHERE name = $1"
 
 	var c models.Category
@@ -87,6 +87,69 @@ func CategoryExists(tx *sql.Tx, c *models.Category) (bool, error) {
 	return exists, err
 }
 
+// GetCategoryTree loads every category, active or not, so each can be placed
+// under its ancestors and amounts rolled up to them.
+func GetCategoryTree(db *sql.DB) (models.CategoryTree, error) {
+	rows, err := db.Query("SELECT id, name, is_active, COALESCE(parent_id, 0) FROM categories")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var categories []models.Category
+
+	for rows.Next() {
+		var c models.Category
+
+		if err := rows.Scan(&c.Id, &c.Name, &c.Active, &c.ParentId); err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, c)
+	}
+
+	return models.NewCategoryTree(categories), rows.Err()
+}
+
+// checkParent makes sure the parent exists and is not the category itself or
+// one of its descendants, which would close a cycle.
+func checkParent(ctx context.Context, tx *sql.Tx, c *models.Category) error {
+	if c.ParentId == 0 {
+		return nil
+	}
+
+	if c.ParentId == c.Id {
+		return errors.New("error - category can't be its own parent")
+	}
+
+	query := `WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM categories WHERE id = $1
+		UNION
+		SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT COUNT(*) > 0, COALESCE(BOOL_OR(id = $2), FALSE) FROM ancestors`
+
+	var found, cycle bool
+
+	err := tx.QueryRowContext(ctx, query, c.ParentId, c.Id).Scan(&found, &cycle)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to verify parent category: %w", err)
+	}
+
+	if !found {
+		return errors.New("error - parent category not found")
+	}
+
+	if cycle {
+		return errors.New("error - parent category is a subcategory of this category")
+	}
+
+	return nil
+}
+
 func SaveCategory(db *sql.DB, c *models.Category) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
 	defer cancel()
@@ -109,11 +172,15 @@ func SaveCategory(db *sql.DB, c *models.Category) error {
 		return errors.New("error - expense already exists")
 	}
 
-	query := "INSERT INTO categories (name) VALUES ($1) RETURNING id"
+	if err := checkParent(ctx, tx, c); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO categories (name, parent_id) VALUES ($1, $2) RETURNING id"
 
 	var id int
 
-	err = tx.QueryRowContext(ctx, query, c.Name).Scan(&id)
+	err = tx.QueryRowContext(ctx, query, c.Name, nullInt(c.ParentId)).Scan(&id)
 
 	if err != nil {
 		return err
@@ -152,9 +219,13 @@ func UpdateCategory(db *sql.DB, c *models.Category) error {
 		return errors.New("error - expense doesn't exists")
 	}
 
-	query := "UPDATE categories SET name = $1, is_active = $2 WHERE id = $3"
+	if err := checkParent(ctx, tx, c); err != nil {
+		return err
+	}
+
+	query := "UPDATE categories SET name = $1, is_active = $2, parent_id = $3 WHERE id = $4"
 
-	res, err := tx.ExecContext(ctx, query, c.Name, c.Active, c.Id)
+	res, err := tx.ExecContext(ctx, query, c.Name, c.Active, nullInt(c.ParentId), c.Id)
 
 	if err != nil {
 		return err
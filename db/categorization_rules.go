@@ -0,0 +1,126 @@
+package db
+
+import (
+	"csv_extractor/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const ruleColumns = `r.id, r.match_type, r.pattern, r.min_amount, r.max_amount, r.category_id, c.name,
+	r.priority, r.is_active`
+
+func scanRule(row rowScanner) (models.CategorizationRule, error) {
+	var r models.CategorizationRule
+
+	err := row.Scan(&r.Id, &r.MatchType, &r.Pattern, &r.MinAmount, &r.MaxAmount, &r.CategoryId, &r.Category,
+		&r.Priority, &r.Active)
+
+	return r, err
+}
+
+// GetAllRules lists rules in the order they are evaluated.
+func GetAllRules(db Querier) ([]models.CategorizationRule, error) {
+	query := `SELECT ` + ruleColumns + `
+	FROM categorization_rules r
+	JOIN categories c ON c.id = r.category_id
+	ORDER BY r.priority DESC, r.id`
+
+	rows, err := db.Query(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var rules []models.CategorizationRule
+
+	for rows.Next() {
+		r, err := scanRule(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+func GetRuleById(db Querier, ruleId int) (*models.CategorizationRule, error) {
+	query := `SELECT ` + ruleColumns + `
+	FROM categorization_rules r
+	JOIN categories c ON c.id = r.category_id
+	WHERE r.id = $1`
+
+	r, err := scanRule(db.QueryRow(query, ruleId))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("rule not found")
+		}
+
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func SaveRule(db Querier, r *models.CategorizationRule) error {
+	query := `INSERT INTO categorization_rules (match_type, pattern, min_amount, max_amount, category_id, priority, is_active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	err := db.QueryRow(query, r.MatchType, r.Pattern, r.MinAmount, r.MaxAmount, r.CategoryId, r.Priority, r.Active).Scan(&r.Id)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save rule: %w", err)
+	}
+
+	return nil
+}
+
+func UpdateRule(db *sql.DB, r *models.CategorizationRule) error {
+	query := `UPDATE categorization_rules
+	SET match_type = $1, pattern = $2, min_amount = $3, max_amount = $4, category_id = $5, priority = $6, is_active = $7
+	WHERE id = $8`
+
+	res, err := db.Exec(query, r.MatchType, r.Pattern, r.MinAmount, r.MaxAmount, r.CategoryId, r.Priority, r.Active, r.Id)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to update rule: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return errors.New("error - failed row verification")
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("rule not found")
+	}
+
+	return nil
+}
+
+func DeleteRule(db *sql.DB, ruleId int) error {
+	res, err := db.Exec("DELETE FROM categorization_rules WHERE id = $1", ruleId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to delete rule: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return errors.New("error - failed row verification")
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("rule not found")
+	}
+
+	return nil
+}
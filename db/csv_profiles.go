@@ -0,0 +1,107 @@
+package db
+
+import (
+	"csv_extractor/models"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const csvProfileColumns = `id, name, delimiter, skip_rows, date_column, title_column, amount_column,
+	debit_column, credit_column, date_layout, decimal_separator, locale, currency, negate, ignore_titles`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCsvProfile(row rowScanner) (models.CsvProfile, error) {
+	var p models.CsvProfile
+
+	err := row.Scan(&p.Id, &p.Name, &p.Delimiter, &p.SkipRows, &p.DateColumn, &p.TitleColumn, &p.AmountColumn,
+		&p.DebitColumn, &p.CreditColumn, &p.DateLayout, &p.DecimalSeparator, &p.Locale, &p.Currency, &p.Negate, pq.Array(&p.IgnoreTitles))
+
+	return p, err
+}
+
+func GetAllCsvProfiles(db *sql.DB) ([]models.CsvProfile, error) {
+	rows, err := db.Query("SELECT " + csvProfileColumns + " FROM csv_profiles ORDER BY name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var profiles []models.CsvProfile
+
+	for rows.Next() {
+		p, err := scanCsvProfile(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	return profiles, rows.Err()
+}
+
+func GetCsvProfileByName(db *sql.DB, name string) (*models.CsvProfile, error) {
+	row := db.QueryRow("SELECT "+csvProfileColumns+" FROM csv_profiles WHERE name = $1", name)
+
+	p, err := scanCsvProfile(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("csv profile not found")
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func SaveCsvProfile(db *sql.DB, p *models.CsvProfile) error {
+	query := `INSERT INTO csv_profiles (name, delimiter, skip_rows, date_column, title_column, amount_column,
+	debit_column, credit_column, date_layout, decimal_separator, locale, currency, negate, ignore_titles)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
+
+	ignore := p.IgnoreTitles
+
+	if ignore == nil {
+		ignore = []string{}
+	}
+
+	err := db.QueryRow(query, p.Name, p.Delimiter, p.SkipRows, p.DateColumn, p.TitleColumn, p.AmountColumn,
+		p.DebitColumn, p.CreditColumn, p.DateLayout, p.DecimalSeparator, p.Locale, p.Currency, p.Negate, pq.Array(ignore)).Scan(&p.Id)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save csv profile: %w", err)
+	}
+
+	return nil
+}
+
+func DeleteCsvProfile(db *sql.DB, profileId int) error {
+	res, err := db.Exec("DELETE FROM csv_profiles WHERE id = $1", profileId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to delete csv profile: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return errors.New("error - failed row verification")
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("csv profile not found")
+	}
+
+	return nil
+}
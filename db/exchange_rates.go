@@ -0,0 +1,70 @@
+package db
+
+import (
+	"csv_extractor/models"
+	"database/sql"
+	"fmt"
+)
+
+// SaveExchangeRates stores the rates in one transaction, replacing any rate
+// already known for the same day and currency pair.
+func SaveExchangeRates(db *sql.DB, rates []models.ExchangeRate) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return fmt.Errorf("error - failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `INSERT INTO exchange_rates (from_currency, to_currency, date, rate)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (from_currency, to_currency, date) DO UPDATE SET rate = EXCLUDED.rate`
+
+	stmt, err := tx.Prepare(query)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to prepare exchange rates: %w", err)
+	}
+
+	defer stmt.Close()
+
+	for _, r := range rates {
+		if _, err := stmt.Exec(r.From, r.To, r.Date, r.Rate); err != nil {
+			return fmt.Errorf("error - failed to save exchange rate %s/%s: %w", r.From, r.To, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetExchangeRates lists the rates involving currency, or every rate when it
+// is empty.
+func GetExchangeRates(db *sql.DB, currency string) ([]models.ExchangeRate, error) {
+	query := `SELECT from_currency, to_currency, date, rate
+	FROM exchange_rates
+	WHERE $1 = '' OR from_currency = $1 OR to_currency = $1
+	ORDER BY from_currency, to_currency, date`
+
+	rows, err := db.Query(query, currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+
+	for rows.Next() {
+		var r models.ExchangeRate
+
+		if err := rows.Scan(&r.From, &r.To, &r.Date, &r.Rate); err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, r)
+	}
+
+	return rates, rows.Err()
+}
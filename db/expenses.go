@@ -116,11 +116,33 @@ func GetExpenseById(db *sql.DB, expenseId int) (*models.Expense, error) {
 	return &e, nil
 }
 
-func GetExpenseByTitle(db *sql.DB, t string) (*models.Expense, error) {
-	query := `SELECT e.id, e.title, c.id, c.name, e.is_active 
+// GetExpenseTitle returns the title of an expense.
+func GetExpenseTitle(db Querier, expenseId int) (string, error) {
+	var title string
+
+	err := db.QueryRow("SELECT title FROM expenses WHERE id = $1", expenseId).Scan(&title)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("expense %d not found", expenseId)
+		}
+
+		return "", err
+	}
+
+	return title, nil
+}
+
+// GetExpenseByTitle finds an expense by its title, ignoring case, or by one of
+// its merchant aliases.
+func GetExpenseByTitle(db Querier, t string) (*models.Expense, error) {
+	query := `SELECT e.id, e.title, c.id, c.name, e.is_active
 	FROM expenses e
 	LEFT JOIN categories c ON e.category_id = c.id
-	WHERE e.title = $1`
+	WHERE e.id = COALESCE(
+		(SELECT id FROM expenses WHERE lower(title) = lower($1) ORDER BY id LIMIT 1),
+		(SELECT expense_id FROM merchant_aliases WHERE alias = lower($1))
+	)`
 
 	var e models.Expense
 
@@ -137,6 +159,104 @@ func GetExpenseByTitle(db *sql.DB, t string) (*models.Expense, error) {
 	return &e, nil
 }
 
+// GetTrainingExpenses lists active expenses categorized by hand or by a rule,
+// outside the default category, each with its average transaction amount.
+func GetTrainingExpenses(db Querier) ([]models.Expense, error) {
+	query := `SELECT e.id, e.title, c.id, c.name, COALESCE(ROUND(AVG(t.amount), 2), 0)
+	FROM expenses e
+	JOIN categories c ON c.id = e.category_id
+	LEFT JOIN transactions t ON t.expense_id = e.id
+	WHERE e.is_active AND c.name <> $1 AND e.category_source IN ($2, $3)
+	GROUP BY e.id, c.id`
+
+	rows, err := db.Query(query, "Outros", models.CategoryManual, models.CategoryRule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var expenses []models.Expense
+
+	for rows.Next() {
+		var e models.Expense
+
+		err := rows.Scan(&e.Id, &e.Title, &e.CategoryId, &e.Category, &e.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
+
+// GetCategorizedExpenses lists active expenses outside the default category.
+func GetCategorizedExpenses(db Querier) ([]models.Expense, error) {
+	query := `SELECT e.id, e.title, c.id, c.name
+	FROM expenses e
+	JOIN categories c ON c.id = e.category_id
+	WHERE e.is_active AND c.name <> $1`
+
+	rows, err := db.Query(query, "Outros")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var expenses []models.Expense
+
+	for rows.Next() {
+		var e models.Expense
+
+		err := rows.Scan(&e.Id, &e.Title, &e.CategoryId, &e.Category)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
+
+func GetExpensesByImport(db *sql.DB, importId int) ([]models.Expense, error) {
+	query := `SELECT e.id, e.title, COALESCE(c.id, 0), COALESCE(c.name, ''), e.is_active
+	FROM expenses e
+	LEFT JOIN categories c ON e.category_id = c.id
+	WHERE e.import_id = $1`
+
+	rows, err := db.Query(query, importId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var expenses []models.Expense
+
+	for rows.Next() {
+		var e models.Expense
+
+		err := rows.Scan(&e.Id, &e.Title, &e.CategoryId, &e.Category, &e.Active)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
+
 func UpdateExpense(db *sql.DB, e *models.Expense) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
 	defer cancel()
@@ -161,9 +281,12 @@ func UpdateExpense(db *sql.DB, e *models.Expense) error {
 		return errors.New("error - expense doesn't exists")
 	}
 
-	query := "UPDATE expenses SET title = $1, is_active = $2, category_id = $3 WHERE id = $4"
+	// a category set by hand is final
+	query := `UPDATE expenses SET title = $1, is_active = $2, category_id = $3,
+	category_source = $4, category_confidence = NULL, needs_review = FALSE, suggested_category_id = NULL
+	WHERE id = $5`
 
-	res, err := tx.ExecContext(ctx, query, e.Title, e.Active, e.CategoryId, e.Id)
+	res, err := tx.ExecContext(ctx, query, e.Title, e.Active, e.CategoryId, models.CategoryManual, e.Id)
 
 	if err != nil {
 		return err
@@ -186,57 +309,53 @@ func UpdateExpense(db *sql.DB, e *models.Expense) error {
 	return nil
 }
 
-func SaveExpensesBatch(db *sql.DB, e map[string]models.Expense) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
-
-	tsx, err := db.BeginTx(ctx, nil)
-
-	if err != nil {
-		log.Fatal("error - failed to start transaction:", err.Error())
-		return err
-	}
-
+// SaveExpensesBatch inserts the expenses that have no id yet, under the
+// default category unless they already carry one. It runs on the caller's
+// transaction.
+func SaveExpensesBatch(ctx context.Context, db Querier, e map[string]models.Expense, importId int) error {
 	defaultCategory, err := GetCategoryByName(db, "Outros")
 
 	if err != nil {
-		log.Fatal("error - failed to get default category:", err.Error())
-		return err
+		return fmt.Errorf("error - failed to get default category: %w", err)
 	}
 
-	defer tsx.Rollback()
+	query := `INSERT INTO expenses (title, category_id, import_id, category_source, category_confidence, needs_review,
+	suggested_category_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
 
-	stmt, err := tsx.PrepareContext(ctx, "INSERT INTO expenses (title, category_id) VALUES ($1, $2) RETURNING id")
+	stmt, err := db.PrepareContext(ctx, query)
 
 	if err != nil {
-		log.Fatal("error - failed to prepare statement:", err.Error())
-		return err
+		return fmt.Errorf("error - failed to prepare statement: %w", err)
 	}
 
 	defer stmt.Close()
 
-	for _, expense := range e {
+	for title, expense := range e {
 		if expense.Id != 0 {
 			continue
 		}
 
 		var newId int
 
-		err := stmt.QueryRowContext(ctx, expense.Title, defaultCategory.Id).Scan(&newId)
+		if expense.CategoryId == 0 {
+			expense.Category = defaultCategory.Name
+			expense.CategoryId = defaultCategory.Id
+			expense.CategorySource = models.CategoryDefault
+		}
+
+		confidence := sql.NullFloat64{Float64: expense.Confidence, Valid: expense.Confidence > 0}
+
+		err := stmt.QueryRowContext(ctx, expense.Title, expense.CategoryId, nullInt(importId), expense.CategorySource,
+			confidence, expense.NeedsReview, nullInt(expense.SuggestedCategoryId)).Scan(&newId)
 
 		if err != nil {
 			return fmt.Errorf("error - failed to save expense %s: %v", expense.Title, err.Error())
 		}
 
 		expense.Id = newId
-		expense.Category = defaultCategory.Name
-		expense.CategoryId = defaultCategory.Id
 
-		e[expense.Title] = expense
-	}
-
-	if err := tsx.Commit(); err != nil {
-		return fmt.Errorf("error - failed to commit transaction: %s", err.Error())
+		e[title] = expense
 	}
 
 	return nil
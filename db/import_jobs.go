@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"csv_extractor/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueueImport registers an import and the job that will process it.
+func QueueImport(db *sql.DB, i *models.Import, j *models.ImportJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	i.Status = models.ImportQueued
+
+	query := `INSERT INTO imports (file_name, checksum, status)
+	VALUES ($1, $2, $3) RETURNING id, uploaded_at`
+
+	err = tx.QueryRowContext(ctx, query, i.FileName, i.Checksum, i.Status).Scan(&i.Id, &i.UploadedAt)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save import: %w", err)
+	}
+
+	j.ImportId = i.Id
+
+	query = `INSERT INTO import_jobs (import_id, file_path, options, bytes_total) VALUES ($1, $2, $3, $4)`
+
+	_, err = tx.ExecContext(ctx, query, j.ImportId, j.FilePath, j.Options, j.BytesTotal)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save import job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error - failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimImportJob marks the oldest queued job as processing and returns it, or
+// nil when the queue is empty. Concurrent workers never claim the same job.
+func ClaimImportJob(db *sql.DB) (*models.ImportJob, error) {
+	query := `UPDATE imports SET status = $1
+	WHERE id = (
+		SELECT i.id FROM imports i
+		JOIN import_jobs j ON j.import_id = i.id
+		WHERE i.status = $2
+		ORDER BY i.id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id`
+
+	var id int
+
+	err := db.QueryRow(query, models.ImportProcessing, models.ImportQueued).Scan(&id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	_, err = db.Exec("UPDATE import_jobs SET started_at = NOW(), finished_at = NULL, error = '' WHERE import_id = $1", id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetImportJob(db, id)
+}
+
+// GetImportJob returns nil for imports that were processed inline.
+func GetImportJob(db *sql.DB, importId int) (*models.ImportJob, error) {
+	query := `SELECT import_id, file_path, options, bytes_total, bytes_read, rows_processed, rejected_count,
+	rejected, started_at, finished_at, error
+	FROM import_jobs
+	WHERE import_id = $1`
+
+	var j models.ImportJob
+	var rejected []byte
+	var started, finished sql.NullTime
+
+	err := db.QueryRow(query, importId).Scan(&j.ImportId, &j.FilePath, &j.Options, &j.BytesTotal, &j.BytesRead,
+		&j.RowsProcessed, &j.RejectedCount, &rejected, &started, &finished, &j.Error)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	j.StartedAt = started.Time
+	j.FinishedAt = finished.Time
+
+	if err := json.Unmarshal(rejected, &j.Rejected); err != nil {
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+func UpdateImportJobProgress(db *sql.DB, j *models.ImportJob) error {
+	query := `UPDATE import_jobs SET bytes_read = $1, rows_processed = $2, rejected_count = $3
+	WHERE import_id = $4`
+
+	_, err := db.Exec(query, j.BytesRead, j.RowsProcessed, j.RejectedCount, j.ImportId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to update import job: %w", err)
+	}
+
+	return nil
+}
+
+func FinishImportJob(db *sql.DB, j *models.ImportJob) error {
+	rejected, err := json.Marshal(j.Rejected)
+
+	if err != nil {
+		return err
+	}
+
+	if j.Rejected == nil {
+		rejected = []byte("[]")
+	}
+
+	query := `UPDATE import_jobs SET bytes_read = $1, rows_processed = $2, rejected_count = $3, rejected = $4,
+	error = $5, finished_at = NOW()
+	WHERE import_id = $6
+	RETURNING finished_at`
+
+	err = db.QueryRow(query, j.BytesRead, j.RowsProcessed, j.RejectedCount, rejected, j.Error, j.ImportId).Scan(&j.FinishedAt)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to finish import job: %w", err)
+	}
+
+	return nil
+}
+
+// RequeueInterruptedImports runs at startup. Jobs cut short by a restart lost
+// their uncommitted rows, so they are queued again; imports that were being
+// processed inline can't be resumed and are marked failed.
+func RequeueInterruptedImports(db *sql.DB) error {
+	query := `UPDATE imports i SET status = $1
+	WHERE i.status = $2 AND EXISTS (SELECT 1 FROM import_jobs j WHERE j.import_id = i.id)`
+
+	if _, err := db.Exec(query, models.ImportQueued, models.ImportProcessing); err != nil {
+		return fmt.Errorf("error - failed to requeue imports: %w", err)
+	}
+
+	query = `UPDATE imports i SET status = $1
+	WHERE i.status = $2 AND NOT EXISTS (SELECT 1 FROM import_jobs j WHERE j.import_id = i.id)`
+
+	if _, err := db.Exec(query, models.ImportFailed, models.ImportProcessing); err != nil {
+		return fmt.Errorf("error - failed to close interrupted imports: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"csv_extractor/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func CreateImport(db *sql.DB, i *models.Import) error {
+	query := `INSERT INTO imports (file_name, checksum, status)
+	VALUES ($1, $2, $3) RETURNING id, uploaded_at`
+
+	err := db.QueryRow(query, i.FileName, i.Checksum, i.Status).Scan(&i.Id, &i.UploadedAt)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save import: %w", err)
+	}
+
+	return nil
+}
+
+func UpdateImport(db *sql.DB, i *models.Import) error {
+	query := `UPDATE imports SET checksum = $1, row_count = $2, total = $3, status = $4,
+	currency = COALESCE(NULLIF($6, ''), currency)
+	WHERE id = $5`
+
+	_, err := db.Exec(query, i.Checksum, i.RowCount, i.Total, i.Status, i.Id, i.Total.Currency)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to update import: %w", err)
+	}
+
+	return nil
+}
+
+func GetAllImports(db *sql.DB) ([]models.Import, error) {
+	query := `SELECT id, file_name, checksum, uploaded_at, row_count, total, currency, status
+	FROM imports
+	ORDER BY uploaded_at DESC`
+
+	rows, err := db.Query(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var imports []models.Import
+
+	for rows.Next() {
+		var i models.Import
+
+		err := rows.Scan(&i.Id, &i.FileName, &i.Checksum, &i.UploadedAt, &i.RowCount, &i.Total, &i.Total.Currency, &i.Status)
+
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, i)
+	}
+
+	return imports, rows.Err()
+}
+
+func GetImportById(db *sql.DB, importId int) (*models.Import, error) {
+	query := `SELECT id, file_name, checksum, uploaded_at, row_count, total, currency, status
+	FROM imports
+	WHERE id = $1`
+
+	var i models.Import
+
+	err := db.QueryRow(query, importId).Scan(&i.Id, &i.FileName, &i.Checksum, &i.UploadedAt, &i.RowCount, &i.Total, &i.Total.Currency, &i.Status)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("import not found")
+		}
+
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// GetImportByChecksum finds a previous upload of the same file. Failed and
+// reverted imports are ignored so the file can be uploaded again.
+func GetImportByChecksum(db *sql.DB, checksum string) (*models.Import, error) {
+	query := `SELECT id, file_name, checksum, uploaded_at, row_count, total, currency, status
+	FROM imports
+	WHERE checksum = $1 AND status NOT IN ($2, $3)
+	LIMIT 1`
+
+	var i models.Import
+
+	err := db.QueryRow(query, checksum, models.ImportFailed, models.ImportReverted).Scan(&i.Id, &i.FileName, &i.Checksum, &i.UploadedAt, &i.RowCount, &i.Total, &i.Total.Currency, &i.Status)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// RevertImport removes the transactions an import created and the expenses it
+// introduced, as long as no other import has transactions pointing at them.
+func RevertImport(db *sql.DB, importId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM transactions WHERE import_id = $1", importId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to delete import transactions: %w", err)
+	}
+
+	query := `DELETE FROM expenses e
+	WHERE e.import_id = $1
+	AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.expense_id = e.id)`
+
+	_, err = tx.ExecContext(ctx, query, importId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to delete import expenses: %w", err)
+	}
+
+	// expenses still used by other imports are kept but no longer owned by this one
+	_, err = tx.ExecContext(ctx, "UPDATE expenses SET import_id = NULL WHERE import_id = $1", importId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to release import expenses: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE imports SET status = $1 WHERE id = $2", models.ImportReverted, importId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to update import: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return errors.New("error - failed row verification")
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("import not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error - failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
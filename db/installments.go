@@ -0,0 +1,46 @@
+package db
+
+import (
+	"csv_extractor/models"
+	"database/sql"
+)
+
+// GetInstallmentPlans lists purchases that still have installments to come.
+func GetInstallmentPlans(db *sql.DB) ([]models.InstallmentPlan, error) {
+	query := `SELECT installment_group, title, amount, currency, installment, installment_total, date
+	FROM (
+		SELECT DISTINCT ON (installment_group) installment_group, title, amount, currency, installment, installment_total, date
+		FROM transactions
+		WHERE installment_group IS NOT NULL
+		ORDER BY installment_group, installment DESC
+	) last
+	WHERE installment < installment_total
+	ORDER BY date, title`
+
+	rows, err := db.Query(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var plans []models.InstallmentPlan
+
+	for rows.Next() {
+		var p models.InstallmentPlan
+
+		err := rows.Scan(&p.Group, &p.Title, &p.Amount, &p.Amount.Currency, &p.Paid, &p.Total, &p.LastDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.Remaining = p.Total - p.Paid
+		p.RemainingAmount = p.Amount.Mul(int64(p.Remaining))
+
+		plans = append(plans, p)
+	}
+
+	return plans, rows.Err()
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"csv_extractor/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func GetMerchantAliases(db *sql.DB, expenseId int) ([]models.MerchantAlias, error) {
+	rows, err := db.Query("SELECT alias, expense_id FROM merchant_aliases WHERE expense_id = $1 ORDER BY alias", expenseId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var aliases []models.MerchantAlias
+
+	for rows.Next() {
+		var a models.MerchantAlias
+
+		if err := rows.Scan(&a.Alias, &a.ExpenseId); err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, a)
+	}
+
+	return aliases, rows.Err()
+}
+
+func saveAlias(ctx context.Context, tx *sql.Tx, alias string, expenseId int) error {
+	query := `INSERT INTO merchant_aliases (alias, expense_id) VALUES ($1, $2)
+	ON CONFLICT (alias) DO UPDATE SET expense_id = EXCLUDED.expense_id`
+
+	_, err := tx.ExecContext(ctx, query, strings.ToLower(alias), expenseId)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to save alias %s: %w", alias, err)
+	}
+
+	return nil
+}
+
+// MergeExpenses folds the source expenses into the target: their transactions
+// and aliases move over, their titles become aliases of the target and they
+// are deleted. The extra aliases send future titles to the target as well.
+func MergeExpenses(db *sql.DB, targetId int, sourceIds []int, aliases []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var target string
+
+	err = tx.QueryRowContext(ctx, "SELECT title FROM expenses WHERE id = $1", targetId).Scan(&target)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("expense not found")
+		}
+
+		return err
+	}
+
+	for _, id := range sourceIds {
+		if id == targetId {
+			continue
+		}
+
+		var title string
+
+		err := tx.QueryRowContext(ctx, "SELECT title FROM expenses WHERE id = $1", id).Scan(&title)
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("expense %d not found", id)
+			}
+
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE transactions SET expense_id = $1 WHERE expense_id = $2", targetId, id)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to move transactions: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE merchant_aliases SET expense_id = $1 WHERE expense_id = $2", targetId, id)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to move aliases: %w", err)
+		}
+
+		if err := saveAlias(ctx, tx, title, targetId); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM expenses WHERE id = $1", id)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to delete merged expense: %w", err)
+		}
+	}
+
+	for _, a := range aliases {
+		// the target's own title needs no alias
+		if strings.EqualFold(a, target) {
+			continue
+		}
+
+		if err := saveAlias(ctx, tx, a, targetId); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error - failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AliasNormalizedTitles points the normalized form of every older expense
+// title at that expense, so rows written by the current parsers still find
+// it. Forms that already name an expense or an alias are left alone.
+func AliasNormalizedTitles(db *sql.DB, normalize func(string) string) error {
+	rows, err := db.Query("SELECT id, title FROM expenses ORDER BY id")
+
+	if err != nil {
+		return fmt.Errorf("error - failed to get expenses: %w", err)
+	}
+
+	aliases := make(map[string]int)
+
+	for rows.Next() {
+		var id int
+		var title string
+
+		if err := rows.Scan(&id, &title); err != nil {
+			rows.Close()
+			return err
+		}
+
+		alias := strings.ToLower(normalize(title))
+
+		if _, ok := aliases[alias]; !ok && alias != "" && alias != strings.ToLower(title) {
+			aliases[alias] = id
+		}
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO merchant_aliases (alias, expense_id)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM expenses WHERE lower(title) = $1)
+	ON CONFLICT (alias) DO NOTHING`
+
+	for alias, id := range aliases {
+		if _, err := db.Exec(query, alias, id); err != nil {
+			return fmt.Errorf("error - failed to save alias %s: %w", alias, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations run in order on every start, so each statement must be idempotent.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS categories (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		is_active BOOLEAN NOT NULL DEFAULT TRUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS expenses (
+		id SERIAL PRIMARY KEY,
+		title TEXT NOT NULL,
+		category_id INTEGER REFERENCES categories (id),
+		is_active BOOLEAN NOT NULL DEFAULT TRUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+		id SERIAL PRIMARY KEY,
+		date DATE NOT NULL,
+		raw_title TEXT NOT NULL,
+		title TEXT NOT NULL,
+		amount DOUBLE PRECISION NOT NULL,
+		expense_id INTEGER NOT NULL REFERENCES expenses (id),
+		import_id INTEGER
+	)`,
+	`CREATE INDEX IF NOT EXISTS transactions_date_idx ON transactions (date)`,
+	`CREATE INDEX IF NOT EXISTS transactions_expense_id_idx ON transactions (expense_id)`,
+	`CREATE TABLE IF NOT EXISTS imports (
+		id SERIAL PRIMARY KEY,
+		file_name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		uploaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		row_count INTEGER NOT NULL DEFAULT 0,
+		total DOUBLE PRECISION NOT NULL DEFAULT 0,
+		status TEXT NOT NULL
+	)`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS import_id INTEGER REFERENCES imports (id)`,
+	`CREATE INDEX IF NOT EXISTS transactions_import_id_idx ON transactions (import_id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fingerprint TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS transactions_fingerprint_idx ON transactions (fingerprint)`,
+	`CREATE INDEX IF NOT EXISTS imports_checksum_idx ON imports (checksum)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_id TEXT`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS installment INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS installment_total INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS installment_group TEXT`,
+	`CREATE INDEX IF NOT EXISTS transactions_installment_group_idx ON transactions (installment_group)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'purchase'`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS refund_of INTEGER REFERENCES transactions (id) ON DELETE SET NULL`,
+	`CREATE TABLE IF NOT EXISTS import_jobs (
+		import_id INTEGER PRIMARY KEY REFERENCES imports (id) ON DELETE CASCADE,
+		file_path TEXT NOT NULL,
+		options JSONB NOT NULL DEFAULT '{}',
+		bytes_total BIGINT NOT NULL DEFAULT 0,
+		bytes_read BIGINT NOT NULL DEFAULT 0,
+		rows_processed INTEGER NOT NULL DEFAULT 0,
+		rejected_count INTEGER NOT NULL DEFAULT 0,
+		rejected JSONB NOT NULL DEFAULT '[]',
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ,
+		error TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS csv_profiles (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		delimiter TEXT NOT NULL DEFAULT ',',
+		skip_rows INTEGER NOT NULL DEFAULT 0,
+		date_column INTEGER NOT NULL,
+		title_column INTEGER NOT NULL,
+		amount_column INTEGER,
+		debit_column INTEGER,
+		credit_column INTEGER,
+		date_layout TEXT NOT NULL,
+		decimal_separator TEXT NOT NULL DEFAULT '',
+		negate BOOLEAN NOT NULL DEFAULT FALSE,
+		ignore_titles TEXT[] NOT NULL DEFAULT '{}'
+	)`,
+	`ALTER TABLE csv_profiles ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT ''`,
+	// amounts were floats at first, rounded to cents only once
+	`DO $$ BEGIN
+		IF (SELECT data_type FROM information_schema.columns
+			WHERE table_name = 'transactions' AND column_name = 'amount') = 'double precision' THEN
+			ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(14, 2) USING ROUND(amount::numeric, 2);
+		END IF;
+
+		IF (SELECT data_type FROM information_schema.columns
+			WHERE table_name = 'imports' AND column_name = 'total') = 'double precision' THEN
+			ALTER TABLE imports ALTER COLUMN total TYPE NUMERIC(14, 2) USING ROUND(total::numeric, 2);
+		END IF;
+	END $$`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'BRL'`,
+	`ALTER TABLE csv_profiles ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS exchange_rates (
+		from_currency TEXT NOT NULL,
+		to_currency TEXT NOT NULL,
+		date DATE NOT NULL,
+		rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+		PRIMARY KEY (from_currency, to_currency, date)
+	)`,
+	`CREATE TABLE IF NOT EXISTS categorization_rules (
+		id SERIAL PRIMARY KEY,
+		match_type TEXT NOT NULL DEFAULT '',
+		pattern TEXT NOT NULL DEFAULT '',
+		min_amount NUMERIC(14, 2),
+		max_amount NUMERIC(14, 2),
+		category_id INTEGER NOT NULL REFERENCES categories (id),
+		priority INTEGER NOT NULL DEFAULT 0,
+		is_active BOOLEAN NOT NULL DEFAULT TRUE
+	)`,
+	// expenses in the default category before sources were tracked were put
+	// there on upload
+	`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'expenses' AND column_name = 'category_source') THEN
+			ALTER TABLE expenses ADD COLUMN category_source TEXT NOT NULL DEFAULT 'manual';
+			UPDATE expenses SET category_source = 'default'
+			WHERE category_id = (SELECT id FROM categories WHERE name = 'Outros');
+		END IF;
+	END $$`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS category_confidence DOUBLE PRECISION`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS needs_review BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS suggested_category_id INTEGER REFERENCES categories (id) ON DELETE SET NULL`,
+	`CREATE INDEX IF NOT EXISTS expenses_lower_title_idx ON expenses (lower(title))`,
+	`CREATE TABLE IF NOT EXISTS merchant_aliases (
+		alias TEXT PRIMARY KEY,
+		expense_id INTEGER NOT NULL REFERENCES expenses (id) ON DELETE CASCADE
+	)`,
+	`ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES categories (id)`,
+	`ALTER TABLE imports ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'BRL'`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_amount NUMERIC(14, 2)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_currency TEXT`,
+	// credits were taken for refunds before income was told apart
+	`UPDATE transactions SET kind = 'income'
+	WHERE kind = 'refund' AND refund_of IS NULL AND raw_title !~* '^(estorno|reembolso|cr[eé]dito)'`,
+}
+
+func Migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		if _, err := db.Exec(m); err != nil {
+			return fmt.Errorf("error - failed to run migration: %w", err)
+		}
+	}
+
+	return nil
+}
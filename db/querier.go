@@ -0,0 +1,18 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx, so lookups can run
+// inside an open import transaction as well as on their own.
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"csv_extractor/models"
+	"database/sql"
+	"time"
+)
+
+// convertedTransactions yields transactions with their amount converted to
+// the $8 currency, using the latest rate on or before each transaction date in
+// either direction. Amounts without a known rate are NULL.
+const convertedTransactions = `SELECT t.id, t.date, t.kind, t.expense_id, t.refund_of,
+	ROUND(t.amount * CASE WHEN t.currency = $8 THEN 1 ELSE r.rate END, 2) AS amount
+	FROM transactions t
+	LEFT JOIN LATERAL (
+		SELECT rate FROM (
+			SELECT date, rate FROM exchange_rates
+			WHERE from_currency = t.currency AND to_currency = $8 AND date <= t.date
+			UNION ALL
+			SELECT date, 1 / rate FROM exchange_rates
+			WHERE from_currency = $8 AND to_currency = t.currency AND date <= t.date
+		) known
+		ORDER BY date DESC
+		LIMIT 1
+	) r ON TRUE`
+
+// categoryPaths yields every category with the names from its top level
+// ancestor down to itself.
+const categoryPaths = `SELECT id, ARRAY[name] AS names FROM categories WHERE parent_id IS NULL
+	UNION ALL
+	SELECT c.id, tree.names || c.name FROM categories c JOIN tree ON c.parent_id = tree.id`
+
+// GetCategoryReport totals each category in the given currency. A level above
+// zero rolls subcategories up into their ancestor at that level, 1 being the
+// top level.
+func GetCategoryReport(db *sql.DB, from, to time.Time, currency string, level int) ([]models.CategoryReport, error) {
+	query := `WITH RECURSIVE tree AS (` + categoryPaths + `),
+	t AS (` + convertedTransactions + `),
+	rolled AS (
+		SELECT t.kind, t.amount, CASE WHEN $9 > 0 THEN tree.names[1:$9] ELSE tree.names END AS names
+		FROM t
+		LEFT JOIN transactions o ON o.id = t.refund_of
+		JOIN expenses e ON e.id = COALESCE(o.expense_id, t.expense_id)
+		LEFT JOIN tree ON tree.id = e.category_id
+		WHERE t.kind <> $7 AND t.kind <> $10
+		AND ($1::date IS NULL OR t.date >= $1) AND ($2::date IS NULL OR t.date <= $2)
+	)
+	SELECT COALESCE(names[array_upper(names, 1)], ''), COALESCE(array_to_string(names, ' > '), ''),
+		COALESCE(SUM(amount) FILTER (WHERE kind = $3), 0),
+		COALESCE(SUM(amount) FILTER (WHERE kind = $4), 0),
+		COALESCE(SUM(amount) FILTER (WHERE kind = $5), 0),
+		COALESCE(SUM(amount) FILTER (WHERE kind = $6), 0),
+		COALESCE(SUM(amount), 0),
+		COUNT(*) FILTER (WHERE amount IS NULL)
+	FROM rolled
+	GROUP BY names
+	ORDER BY SUM(amount) DESC NULLS LAST`
+
+	rows, err := db.Query(query, nullTime(from), nullTime(to),
+		models.KindPurchase, models.KindRefund, models.KindFee, models.KindInterest, models.KindPayment, currency, level, models.KindIncome)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var report []models.CategoryReport
+
+	for rows.Next() {
+		r := models.CategoryReport{Currency: currency}
+
+		r.Purchases.Currency = currency
+		r.Refunds.Currency = currency
+		r.Fees.Currency = currency
+		r.Interest.Currency = currency
+		r.Total.Currency = currency
+
+		err := rows.Scan(&r.Category, &r.Path, &r.Purchases, &r.Refunds, &r.Fees, &r.Interest, &r.Total, &r.Unconverted)
+
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, r)
+	}
+
+	return report, rows.Err()
+}
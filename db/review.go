@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"csv_extractor/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetReviewQueue lists active expenses in the default category, flagged for
+// review or categorized by the classifier or a fuzzy match scoring under
+// threshold, the ones with the most spending first. Payments and income are
+// not counted as spending.
+func GetReviewQueue(db *sql.DB, limit int, threshold float64) ([]models.ReviewItem, error) {
+	query := `SELECT e.id, e.title, COALESCE(c.id, 0), COALESCE(c.name, ''), e.category_source,
+		COALESCE(e.category_confidence, 0), COALESCE(s.id, 0), COALESCE(s.name, ''),
+		COUNT(t.id), COALESCE(SUM(t.amount), 0)
+	FROM expenses e
+	LEFT JOIN categories c ON c.id = e.category_id
+	LEFT JOIN categories s ON s.id = e.suggested_category_id
+	LEFT JOIN transactions t ON t.expense_id = e.id AND t.kind <> $2 AND t.kind <> $4
+	WHERE e.is_active AND (e.needs_review OR c.name = $1 OR c.id IS NULL
+		OR (e.category_source IN ($5, $6) AND COALESCE(e.category_confidence, 0) < $7))
+	GROUP BY e.id, c.id, s.id
+	ORDER BY COALESCE(SUM(t.amount), 0) DESC, e.id
+	LIMIT $3`
+
+	rows, err := db.Query(query, "Outros", models.KindPayment, limit, models.KindIncome,
+		models.CategoryClassifier, models.CategoryFuzzy, threshold)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []models.ReviewItem
+
+	for rows.Next() {
+		var i models.ReviewItem
+
+		err := rows.Scan(&i.ExpenseId, &i.Title, &i.CategoryId, &i.Category, &i.CategorySource, &i.Confidence,
+			&i.SuggestedCategoryId, &i.SuggestedCategory, &i.Transactions, &i.Total)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+// ApplyReviewDecisions categorizes every expense by hand and saves the rules
+// the decisions asked for, built and checked by the caller, in one
+// transaction.
+func ApplyReviewDecisions(db *sql.DB, decisions []models.ReviewDecision, rules []models.CategorizationRule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE expenses SET category_id = $1, category_source = $2, category_confidence = NULL,
+	needs_review = FALSE, suggested_category_id = NULL
+	WHERE id = $3`
+
+	for _, d := range decisions {
+		res, err := tx.ExecContext(ctx, query, d.CategoryId, models.CategoryManual, d.ExpenseId)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to update expense %d: %w", d.ExpenseId, err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("expense %d not found", d.ExpenseId)
+		}
+	}
+
+	for i := range rules {
+		if err := SaveRule(tx, &rules[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error - failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"csv_extractor/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const transactionColumns = `id, date, raw_title, title, amount, currency,
+	original_amount, COALESCE(original_currency, ''), expense_id, COALESCE(import_id, 0),
+	COALESCE(fingerprint, ''), COALESCE(external_id, ''),
+	installment, installment_total, COALESCE(installment_group, ''), kind, COALESCE(refund_of, 0)`
+
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// moneyCurrency is the currency column of an optional amount.
+func moneyCurrency(m *models.Money) sql.NullString {
+	if m == nil {
+		return sql.NullString{}
+	}
+
+	return nullString(m.Currency)
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// CopyTransactions streams a chunk of transactions into the table with COPY.
+// Ids are not read back.
+func CopyTransactions(ctx context.Context, tx *sql.Tx, ts []models.Transaction) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("transactions", "date", "raw_title", "title", "amount", "currency",
+		"original_amount", "original_currency", "expense_id", "import_id", "fingerprint", "external_id", "installment", "installment_total",
+		"installment_group", "kind"))
+
+	if err != nil {
+		return fmt.Errorf("error - failed to prepare copy: %w", err)
+	}
+
+	defer stmt.Close()
+
+	for _, t := range ts {
+		_, err := stmt.ExecContext(ctx, t.Date, t.RawTitle, t.Title, t.Value, t.Value.Currency,
+			t.OriginalValue, moneyCurrency(t.OriginalValue), t.ExpenseId,
+			nullInt(t.ImportId), nullString(t.Fingerprint), nullString(t.ExternalId), t.Installment,
+			t.InstallmentTotal, nullString(t.InstallmentGroup), t.Kind)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to copy transaction %s: %w", t.RawTitle, err)
+		}
+	}
+
+	// an empty exec flushes the buffered rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("error - failed to copy transactions: %w", err)
+	}
+
+	return nil
+}
+
+// LinkRefunds points each refund of an import at the latest earlier purchase of
+// the same expense, preferring one with the exact refunded amount.
+func LinkRefunds(db Querier, importId int) error {
+	query := `UPDATE transactions r SET refund_of = (
+		SELECT p.id FROM transactions p
+		WHERE p.expense_id = r.expense_id AND p.kind = $2 AND p.currency = r.currency
+		AND p.date <= r.date AND p.amount >= -r.amount
+		ORDER BY (p.amount = -r.amount) DESC, p.date DESC, p.id DESC
+		LIMIT 1
+	)
+	WHERE r.import_id = $1 AND r.kind = $3`
+
+	_, err := db.Exec(query, importId, models.KindPurchase, models.KindRefund)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to link refunds: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactions lists transactions between from and to, both inclusive.
+// A zero time leaves that side of the range open.
+func GetTransactions(db *sql.DB, from, to time.Time) ([]models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + `
+	FROM transactions
+	WHERE ($1::date IS NULL OR date >= $1) AND ($2::date IS NULL OR date <= $2)
+	ORDER BY date, id`
+
+	rows, err := db.Query(query, nullTime(from), nullTime(to))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTransactions(rows)
+}
+
+func GetTransactionsByImport(db *sql.DB, importId int) ([]models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + `
+	FROM transactions
+	WHERE import_id = $1
+	ORDER BY date, id`
+
+	rows, err := db.Query(query, importId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTransactions(rows)
+}
+
+// GetExistingFingerprints reports which of the given fingerprints are already
+// stored, so re-uploaded rows can be told apart from new ones.
+func GetExistingFingerprints(db Querier, fps []string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT fingerprint FROM transactions WHERE fingerprint = ANY($1)", pq.Array(fps))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+
+	for rows.Next() {
+		var fp string
+
+		if err := rows.Scan(&fp); err != nil {
+			return nil, err
+		}
+
+		existing[fp] = true
+	}
+
+	return existing, rows.Err()
+}
+
+func scanTransactions(rows *sql.Rows) ([]models.Transaction, error) {
+	defer rows.Close()
+
+	var transactions []models.Transaction
+
+	for rows.Next() {
+		var t models.Transaction
+		var originalCurrency string
+
+		err := rows.Scan(&t.Id, &t.Date, &t.RawTitle, &t.Title, &t.Value, &t.Value.Currency,
+			&t.OriginalValue, &originalCurrency, &t.ExpenseId, &t.ImportId, &t.Fingerprint, &t.ExternalId,
+			&t.Installment, &t.InstallmentTotal, &t.InstallmentGroup, &t.Kind, &t.RefundOf)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if t.OriginalValue != nil {
+			t.OriginalValue.Currency = originalCurrency
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
@@ -29,6 +29,18 @@ func GetCategories(w http.ResponseWriter, r *http.Request) {
 	utils.DataResponse(w, "Successiful request", c)
 }
 
+// GetCategoryTree lists every category nested under its parent.
+func GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := db.GetCategoryTree(db.Database)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", tree.Nodes())
+}
+
 func SaveCategory(w http.ResponseWriter, r *http.Request) {
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
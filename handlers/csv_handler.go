@@ -1,159 +1,135 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"mime/multipart"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
-	"strings"
 
-	"csv_extractor/db"
-	"csv_extractor/models"
+	"csv_extractor/importer"
+	"csv_extractor/parsers"
 	"csv_extractor/utils"
 )
 
-func formatString(s string) string {
-	var ns = s
+// defaultMaxUpload applies when UPLOAD_MAX_BYTES is not set.
+const defaultMaxUpload = 512 << 20
 
-	if strings.Contains(s, " - Parcela") {
-		idx := strings.Index(s, " - Parcela")
+// defaultAsyncUpload applies when IMPORT_ASYNC_BYTES is not set.
+const defaultAsyncUpload = 20 << 20
 
-		ns = s[:idx]
+func envBytes(name string, fallback int64) int64 {
+	n, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+
+	if err != nil || n <= 0 {
+		return fallback
 	}
 
-	if strings.Contains(ns, " - NuPay") {
-		idx := strings.Index(ns, " - NuPay")
+	return n
+}
 
-		ns = ns[:idx]
-	}
+// importErrorResponse maps importer failures to response codes.
+func importErrorResponse(w http.ResponseWriter, result *importer.Result, err error) {
+	var tooLarge *http.MaxBytesError
 
-	return ns
+	switch {
+	case errors.As(err, &tooLarge):
+		utils.ErrorResponse(w, "Error: file is too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, importer.ErrUnknownFormat):
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, importer.ErrInvalidLines):
+		utils.ErrorDataResponse(w, "Error: statement has invalid lines", http.StatusUnprocessableEntity, result.Rejected)
+	case errors.Is(err, importer.ErrMixedCurrency):
+		utils.ErrorResponse(w, "Error: "+err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, importer.ErrUnreadable):
+		fmt.Println("Statement reading error:", err)
+		utils.ErrorResponse(w, "Error: reading file: "+err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, importer.ErrDuplicateFile), errors.Is(err, importer.ErrDuplicateRows):
+		utils.ErrorResponse(w, "Error: "+err.Error(), http.StatusConflict)
+	default:
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
-func GetCsvExpenses(file multipart.File) (map[string]models.Expense, error) {
-	// create csv reader
-	reader := csv.NewReader(file)
+// CsvUploadHandler streams the file part straight into the importer, so the
+// upload is never buffered as a whole. Options are read from the query string
+// or from form fields sent before the file. Large uploads, or any upload with
+// async=true, are queued and answered with 202 and the import to poll.
+func CsvUploadHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, envBytes("UPLOAD_MAX_BYTES", defaultMaxUpload))
 
-	// removing header
-	_, _ = reader.Read()
+	mr, err := r.MultipartReader()
 
-	var expenses = make(map[string]models.Expense)
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := r.URL.Query()
 
 	for {
-		row, err := reader.Read()
+		part, err := mr.NextPart()
 
 		if err != nil {
-			if err.Error() == "EOF" {
-				break
+			if errors.Is(err, io.EOF) {
+				utils.ErrorResponse(w, "Error: File upload", http.StatusBadRequest)
+				return
 			}
 
-			fmt.Println("Line reading error", err)
-
-			return expenses, errors.New("line reading error")
+			importErrorResponse(w, nil, err)
+			return
 		}
 
-		title := formatString(row[1])
+		if part.FormName() != "file" {
+			v, err := io.ReadAll(io.LimitReader(part, 1024))
 
-		if title == "Pagamento recebido" {
-			continue
-		}
-
-		value, err := strconv.ParseFloat(row[2], 64)
+			if err != nil {
+				importErrorResponse(w, nil, err)
+				return
+			}
 
-		if err != nil {
-			fmt.Println("Value conversion error:", err)
-			return expenses, errors.New("value conversion error")
+			values.Set(part.FormName(), string(v))
+			continue
 		}
 
-		if expense, ok := expenses[title]; ok {
-			expense.Value += value
-			expenses[title] = expense
-		} else {
-			e := models.Expense{
-				Title: title,
-				Value: value,
-			}
-
-			expenses[title] = e
+		if !importer.Accepted(part.FileName(), part.Header.Get("Content-Type")) {
+			utils.ErrorResponse(w, "Error: File isn't a csv, ofx or xlsx statement", http.StatusUnsupportedMediaType)
+			return
 		}
-	}
 
-	return expenses, nil
-}
+		opts := importer.OptionsFromValues(values)
+		async, _ := strconv.ParseBool(values.Get("async"))
 
-func GetExpensesCategories(es map[string]models.Expense) error {
-	var hasNewExpense bool
-	for t, e := range es {
-		eData, err := db.GetExpenseByTitle(db.Database, e.Title)
+		if !opts.DryRun && (async || r.ContentLength > envBytes("IMPORT_ASYNC_BYTES", defaultAsyncUpload)) {
+			imp, err := importer.Enqueue(part, part.FileName(), opts)
 
-		if err != nil {
-			return err
-		}
+			if err != nil {
+				importErrorResponse(w, nil, err)
+				return
+			}
 
-		if eData == nil {
-			hasNewExpense = true
-			continue
+			utils.AcceptedResponse(w, "import queued", imp)
+			return
 		}
 
-		e.Id = eData.Id
-		e.Active = eData.Active
-		e.CategoryId = eData.CategoryId
-		e.Category = eData.Category
-
-		es[t] = e
-	}
-
-	if hasNewExpense {
-		err := db.SaveExpensesBatch(db.Database, es)
+		result, err := importer.Run(r.Context(), part, part.FileName(), opts)
 
 		if err != nil {
-			return err
+			importErrorResponse(w, result, err)
+			return
 		}
-	}
-
-	return nil
-}
 
-func CsvUploadHandler(w http.ResponseWriter, r *http.Request) {
-	// get form data
-	err := r.ParseMultipartForm(32 << 20)
-
-	if err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	file, h, err := r.FormFile("file")
-
-	if err != nil {
-		utils.ErrorResponse(w, "Error: File upload", http.StatusBadRequest)
-		return
-	}
-
-	if ct := h.Header.Get("Content-Type"); ct != "text/csv" {
-		utils.ErrorResponse(w, "Error: File isn't a csv", http.StatusUnsupportedMediaType)
-		return
-	}
-
-	defer file.Close()
-
-	// extract expenses from csv file
-	expenses, err := GetCsvExpenses(file)
-
-	if err != nil {
-		utils.ErrorResponse(w, "Error: reading file", http.StatusInternalServerError)
-		return
-	}
-
-	// get/insert expenses from database
-	err = GetExpensesCategories(expenses)
+		if result.DryRun {
+			utils.DataResponse(w, "dry run, nothing was saved", result)
+			return
+		}
 
-	if err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		utils.DataResponse(w, "success", result)
 		return
 	}
+}
 
-	utils.DataResponse(w, "success", expenses)
+func GetFormats(w http.ResponseWriter, r *http.Request) {
+	utils.DataResponse(w, "Successiful request", parsers.Formats())
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/parsers"
+	"csv_extractor/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func GetCsvProfiles(w http.ResponseWriter, r *http.Request) {
+	p, err := db.GetAllCsvProfiles(db.Database)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", p)
+}
+
+func SaveCsvProfile(w http.ResponseWriter, r *http.Request) {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	var p models.CsvProfile
+
+	err := dec.Decode(&p)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = parsers.ValidateCsvProfile(p)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.SaveCsvProfile(db.Database, &p)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", p)
+}
+
+func DeleteCsvProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteCsvProfile(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Successiful request")
+}
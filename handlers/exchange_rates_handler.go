@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/parsers"
+	"csv_extractor/utils"
+)
+
+// defaultMaxRatesUpload caps rate files, which are small even for decades of
+// daily quotes.
+const defaultMaxRatesUpload = 16 << 20
+
+type ratesImportResult struct {
+	Imported int
+	Rejected []models.LineError
+}
+
+// ratesFile returns the uploaded file part, or the body itself when the file
+// is posted as plain csv.
+func ratesFile(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := mr.NextPart()
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("Error: File upload")
+			}
+
+			return nil, err
+		}
+
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+func ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, defaultMaxRatesUpload)
+
+	file, err := ratesFile(r)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rates, rejected, err := parsers.ParseExchangeRates(file)
+
+	if err != nil {
+		utils.ErrorResponse(w, "Error: reading file: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	result := ratesImportResult{Imported: len(rates), Rejected: rejected}
+
+	if len(rates) == 0 {
+		utils.ErrorDataResponse(w, "Error: file has no valid exchange rates", http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	err = db.SaveExchangeRates(db.Database, rates)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", result)
+}
+
+func GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var currency string
+
+	if v := r.URL.Query().Get("currency"); v != "" {
+		var err error
+
+		if currency, err = parsers.CurrencyCode(v); err != nil {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rates, err := db.GetExchangeRates(db.Database, currency)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", rates)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"csv_extractor/db"
 	"csv_extractor/models"
+	"csv_extractor/parsers"
 	"csv_extractor/utils"
 	"encoding/json"
 	"net/http"
@@ -113,3 +114,79 @@ func DisableExpense(w http.ResponseWriter, r *http.Request) {
 
 	utils.SuccessResponse(w, "Successiful request")
 }
+
+// mergeRequest lists the expenses folded into the one in the path and any
+// other title variants that should land on it.
+type mergeRequest struct {
+	ExpenseIds []int
+	Aliases    []string
+}
+
+func MergeExpenses(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	var req mergeRequest
+
+	err = dec.Decode(&req)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.ExpenseIds) == 0 && len(req.Aliases) == 0 {
+		utils.ErrorResponse(w, "Error: nothing to merge", http.StatusBadRequest)
+		return
+	}
+
+	// aliases are matched against titles as the parsers write them
+	aliases := make([]string, 0, len(req.Aliases))
+
+	for _, a := range req.Aliases {
+		if a = parsers.NormalizeMerchant(a); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+
+	err = db.MergeExpenses(db.Database, id, req.ExpenseIds, aliases)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	merged, err := db.GetMerchantAliases(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", merged)
+}
+
+func GetMerchantAliases(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	aliases, err := db.GetMerchantAliases(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", aliases)
+}
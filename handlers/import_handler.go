@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"csv_extractor/db"
+	"csv_extractor/importer"
+	"csv_extractor/models"
+	"csv_extractor/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type importDetail struct {
+	Import       models.Import
+	Expenses     []models.Expense
+	Transactions []models.Transaction
+}
+
+func GetImports(w http.ResponseWriter, r *http.Request) {
+	i, err := db.GetAllImports(db.Database)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", i)
+}
+
+func GetImport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp, err := db.GetImportById(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	e, err := db.GetExpensesByImport(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	t, err := db.GetTransactionsByImport(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", importDetail{
+		Import:       *imp,
+		Expenses:     e,
+		Transactions: t,
+	})
+}
+
+func RevertImport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp, err := db.GetImportById(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if imp.Status == models.ImportReverted {
+		utils.ErrorResponse(w, "Error: import already reverted", http.StatusConflict)
+		return
+	}
+
+	if imp.Status == models.ImportQueued || imp.Status == models.ImportProcessing {
+		utils.ErrorResponse(w, "Error: import is still running", http.StatusConflict)
+		return
+	}
+
+	err = db.RevertImport(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Successiful request")
+}
+
+func GetImportStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, err := importer.Status(id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", status)
+}
+
+// heartbeatInterval keeps idle event streams from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+// GetImportEvents streams the progress of an import as Server-Sent Events and
+// ends with a summary event once the import is finished.
+func GetImportEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// subscribe before reading the status so the end of the import can't be missed
+	events, cancel := importer.Subscribe(id)
+	defer cancel()
+
+	status, err := importer.Status(id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	f, ok := utils.StartEventStream(w)
+
+	if !ok {
+		utils.ErrorResponse(w, "Error: streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	if status.Status != models.ImportQueued && status.Status != models.ImportProcessing {
+		utils.SendEvent(w, f, importer.EventSummary, status)
+		return
+	}
+
+	utils.SendEvent(w, f, importer.EventProgress, status)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			f.Flush()
+		case e, ok := <-events:
+			if !ok {
+				status, err := importer.Status(id)
+
+				if err == nil {
+					utils.SendEvent(w, f, importer.EventSummary, status)
+				}
+
+				return
+			}
+
+			if err := utils.SendEvent(w, f, e.Type, e.Data); err != nil {
+				return
+			}
+		}
+	}
+}
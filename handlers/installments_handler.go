@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/utils"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type installmentsResult struct {
+	Plans      []models.InstallmentPlan
+	Projection []models.MonthlyCommitment
+}
+
+type commitmentKey struct {
+	month    string
+	currency string
+}
+
+// projectInstallments spreads the remaining installments over the months
+// following the last one imported, keeping each currency apart.
+func projectInstallments(plans []models.InstallmentPlan) []models.MonthlyCommitment {
+	months := make(map[commitmentKey]models.Money)
+
+	for _, p := range plans {
+		for i := 1; i <= p.Remaining; i++ {
+			// counted from the first day, as a month after Jan 31 would already be March
+			month := time.Date(p.LastDate.Year(), p.LastDate.Month()+time.Month(i), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+			key := commitmentKey{month, p.Amount.Currency}
+			months[key] = months[key].Add(p.Amount)
+		}
+	}
+
+	projection := make([]models.MonthlyCommitment, 0, len(months))
+
+	for k, v := range months {
+		projection = append(projection, models.MonthlyCommitment{Month: k.month, Amount: v})
+	}
+
+	sort.Slice(projection, func(i, j int) bool {
+		if projection[i].Month != projection[j].Month {
+			return projection[i].Month < projection[j].Month
+		}
+
+		return projection[i].Amount.Currency < projection[j].Amount.Currency
+	})
+
+	return projection
+}
+
+func GetInstallments(w http.ResponseWriter, r *http.Request) {
+	plans, err := db.GetInstallmentPlans(db.Database)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", installmentsResult{
+		Plans:      plans,
+		Projection: projectInstallments(plans),
+	})
+}
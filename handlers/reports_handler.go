@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/parsers"
+	"csv_extractor/utils"
+	"net/http"
+	"strconv"
+)
+
+// currencyParam reads the currency reports are converted to, BRL by default.
+func currencyParam(r *http.Request) (string, error) {
+	v := r.URL.Query().Get("currency")
+
+	if v == "" {
+		return models.DefaultCurrency, nil
+	}
+
+	return parsers.CurrencyCode(v)
+}
+
+func GetCategoryReport(w http.ResponseWriter, r *http.Request) {
+	from, err := parseDateParam(r, "from")
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseDateParam(r, "to")
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	currency, err := currencyParam(r)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	level := 0
+
+	if v := r.URL.Query().Get("level"); v != "" {
+		if level, err = strconv.Atoi(v); err != nil || level < 0 {
+			utils.ErrorResponse(w, "Error: level must be zero or a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := db.GetCategoryReport(db.Database, from, to, currency, level)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", report)
+}
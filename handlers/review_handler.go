@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"csv_extractor/categorizer"
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// defaultReviewLimit applies when the limit parameter is not set.
+const defaultReviewLimit = 100
+
+// reviewThreshold is the confidence under which classifier and fuzzy match
+// assignments are queued for review, from REVIEW_CONFIDENCE_THRESHOLD.
+func reviewThreshold() float64 {
+	f, err := strconv.ParseFloat(os.Getenv("REVIEW_CONFIDENCE_THRESHOLD"), 64)
+
+	if err != nil || f <= 0 || f > 1 {
+		return 0.9
+	}
+
+	return f
+}
+
+type reviewDecisionsRequest struct {
+	Decisions []models.ReviewDecision
+}
+
+type reviewDecisionsResult struct {
+	Updated int
+	Rules   []models.CategorizationRule
+}
+
+func GetReviewQueue(w http.ResponseWriter, r *http.Request) {
+	limit := defaultReviewLimit
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(w, "Error: limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+
+		limit = n
+	}
+
+	items, err := db.GetReviewQueue(db.Database, limit, reviewThreshold())
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", items)
+}
+
+// checkDecision makes sure the category exists and returns the rule the
+// decision asks for, validated, or nil.
+func checkDecision(d models.ReviewDecision, categories map[int]string) (*models.CategorizationRule, error) {
+	if d.ExpenseId == 0 {
+		return nil, errors.New("expense is required")
+	}
+
+	if _, ok := categories[d.CategoryId]; !ok {
+		c, err := db.GetCategoryById(db.Database, d.CategoryId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		categories[c.Id] = c.Name
+	}
+
+	if !d.CreateRule {
+		return nil, nil
+	}
+
+	title, err := db.GetExpenseTitle(db.Database, d.ExpenseId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule := d.Rule(title)
+
+	if err := categorizer.ValidateRule(rule); err != nil {
+		return nil, err
+	}
+
+	rule.Category = categories[rule.CategoryId]
+
+	return &rule, nil
+}
+
+func SaveReviewDecisions(w http.ResponseWriter, r *http.Request) {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	var req reviewDecisionsRequest
+
+	err := dec.Decode(&req)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Decisions) == 0 {
+		utils.ErrorResponse(w, "Error: no decisions", http.StatusBadRequest)
+		return
+	}
+
+	categories := make(map[int]string)
+
+	var rules []models.CategorizationRule
+
+	for i, d := range req.Decisions {
+		rule, err := checkDecision(d, categories)
+
+		if err != nil {
+			utils.ErrorResponse(w, fmt.Sprintf("decision %d: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if rule != nil {
+			rules = append(rules, *rule)
+		}
+	}
+
+	err = db.ApplyReviewDecisions(db.Database, req.Decisions, rules)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", reviewDecisionsResult{Updated: len(req.Decisions), Rules: rules})
+}
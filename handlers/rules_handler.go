@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"csv_extractor/categorizer"
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func decodeRule(r *http.Request) (*models.CategorizationRule, error) {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	var rule models.CategorizationRule
+
+	if err := dec.Decode(&rule); err != nil {
+		return nil, err
+	}
+
+	if err := categorizer.ValidateRule(rule); err != nil {
+		return nil, err
+	}
+
+	c, err := db.GetCategoryById(db.Database, rule.CategoryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Category = c.Name
+
+	return &rule, nil
+}
+
+func GetRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := db.GetAllRules(db.Database)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", rules)
+}
+
+func SaveRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeRule(r)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule.Active = true
+
+	err = db.SaveRule(db.Database, rule)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", rule)
+}
+
+func UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := decodeRule(r)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule.Id = id
+
+	err = db.UpdateRule(db.Database, rule)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", rule)
+}
+
+func DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteRule(db.Database, id)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Successiful request")
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"csv_extractor/db"
+	"csv_extractor/utils"
+	"net/http"
+	"time"
+)
+
+func parseDateParam(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.DateOnly, v)
+}
+
+func GetTransactions(w http.ResponseWriter, r *http.Request) {
+	from, err := parseDateParam(r, "from")
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseDateParam(r, "to")
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t, err := db.GetTransactions(db.Database, from, to)
+
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.DataResponse(w, "Successiful request", t)
+}
@@ -0,0 +1,102 @@
+package importer
+
+import "sync"
+
+const (
+	EventProgress = "progress"
+	EventTitle    = "title"
+	EventCategory = "category"
+	EventSummary  = "summary"
+)
+
+// Event is a live update about an import, published while it runs.
+type Event struct {
+	Type string
+	Data any
+}
+
+type ProgressEvent struct {
+	RowsProcessed  int
+	RejectedCount  int
+	DuplicateCount int
+}
+
+type CategoryEvent struct {
+	Title    string
+	Category string
+	NewTitle bool
+	// RuleId is the categorization rule that picked the category, if any.
+	RuleId int
+	// Confidence is the classifier's, for titles it categorized or left for
+	// review.
+	Confidence  float64
+	NeedsReview bool
+}
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before
+// further events are dropped for it.
+const subscriberBuffer = 256
+
+var subscribers = struct {
+	sync.Mutex
+	byImport map[int]map[chan Event]struct{}
+}{byImport: make(map[int]map[chan Event]struct{})}
+
+// Subscribe returns the events of an import. The channel is closed once the
+// import finishes; cancel must be called when the caller stops listening.
+func Subscribe(importId int) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	if subscribers.byImport[importId] == nil {
+		subscribers.byImport[importId] = make(map[chan Event]struct{})
+	}
+
+	subscribers.byImport[importId][ch] = struct{}{}
+
+	cancel := func() {
+		subscribers.Lock()
+		defer subscribers.Unlock()
+
+		if _, ok := subscribers.byImport[importId][ch]; ok {
+			delete(subscribers.byImport[importId], ch)
+			close(ch)
+		}
+
+		if len(subscribers.byImport[importId]) == 0 {
+			delete(subscribers.byImport, importId)
+		}
+	}
+
+	return ch, cancel
+}
+
+func publish(importId int, e Event) {
+	if importId == 0 {
+		return
+	}
+
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	for ch := range subscribers.byImport[importId] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// closeSubscribers ends every stream of a finished import.
+func closeSubscribers(importId int) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	for ch := range subscribers.byImport[importId] {
+		close(ch)
+	}
+
+	delete(subscribers.byImport, importId)
+}
@@ -0,0 +1,727 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"csv_extractor/categorizer"
+	"csv_extractor/db"
+	"csv_extractor/models"
+	"csv_extractor/parsers"
+)
+
+// chunkSize bounds how many parsed rows are held before they are written.
+const chunkSize = 1000
+
+// reportLimit caps the rows echoed back in a result. Counts stay exact.
+const reportLimit = 1000
+
+var (
+	ErrUnknownFormat  = errors.New("unknown statement format")
+	ErrUnreadable     = errors.New("statement could not be read")
+	ErrInvalidLines   = errors.New("statement has invalid lines")
+	ErrDuplicateFile  = errors.New("file already imported")
+	ErrDuplicateRows  = errors.New("rows were already imported")
+	ErrMixedCurrency  = errors.New("statement mixes currencies")
+	errDefaultMissing = errors.New("default category not found")
+)
+
+type PreviewRow struct {
+	Transaction models.Transaction
+	Category    string
+	NewTitle    bool
+}
+
+type Result struct {
+	Import         models.Import
+	DryRun         bool
+	Expenses       map[string]models.Expense
+	NewTitles      []string
+	Rows           []PreviewRow
+	Duplicates     []models.Transaction
+	DuplicateCount int
+	Rejected       []models.LineError
+	RejectedCount  int
+	// FuzzyMatches are new titles that took the category of a similar
+	// existing expense, to be confirmed with a merge or corrected.
+	FuzzyMatches []categorizer.Match
+}
+
+type importer struct {
+	ctx        context.Context
+	tx         *sql.Tx
+	opts       Options
+	result     *Result
+	chunk      []models.Transaction
+	currency   string
+	seen       occurrences
+	category   *models.Category
+	rules      *categorizer.Rules
+	classifier *categorizer.Classifier
+	titles     *categorizer.Index
+	// ruleIds remembers which rule categorized each new title.
+	ruleIds  map[string]int
+	progress func(*Result)
+	// thresholds of the fuzzy matcher and classifier, read once per import
+	fuzzyThreshold float64
+	minConfidence  float64
+	minExamples    int
+}
+
+// Run parses a statement and stores its transactions inside one database
+// transaction, writing them in chunks as the source is read. Nothing is kept
+// when it fails. The returned result is set even on failure when it explains
+// the error, such as the rejected lines of ErrInvalidLines.
+func Run(ctx context.Context, src io.Reader, fileName string, opts Options) (*Result, error) {
+	result, err := RunImport(ctx, src, &models.Import{FileName: fileName}, opts, nil)
+
+	if result != nil {
+		closeSubscribers(result.Import.Id)
+	}
+
+	return result, err
+}
+
+// RunImport is Run for an import that may already be registered, as queued
+// jobs are. progress, when set, is called after every chunk written. The
+// caller closes the event streams once it has stored the rest of the outcome.
+func RunImport(ctx context.Context, src io.Reader, imp *models.Import, opts Options, progress func(*Result)) (*Result, error) {
+	hash := sha256.New()
+	br := bufio.NewReader(io.TeeReader(src, hash))
+
+	im := &importer{
+		ctx:            ctx,
+		opts:           opts,
+		ruleIds:        make(map[string]int),
+		progress:       progress,
+		fuzzyThreshold: envFloat("FUZZY_MATCH_THRESHOLD", 0.85),
+		minConfidence:  envFloat("CLASSIFIER_MIN_CONFIDENCE", 0.8),
+		minExamples:    envInt("CLASSIFIER_MIN_EXAMPLES", 100),
+		result: &Result{
+			DryRun:   opts.DryRun,
+			Expenses: make(map[string]models.Expense),
+			Import:   *imp,
+		},
+	}
+
+	// also releases the transaction when parsing panics
+	defer func() {
+		if im.tx != nil {
+			im.tx.Rollback()
+		}
+	}()
+
+	text, err := decode(br, opts.Encoding)
+
+	var parser parsers.StatementParser
+
+	if err == nil {
+		parser, err = SelectParser(text, opts)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+
+		if imp.Id != 0 {
+			return im.finish(err)
+		}
+
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		im.result.Import.Status = models.ImportProcessing
+
+		if imp.Id == 0 {
+			err = db.CreateImport(db.Database, &im.result.Import)
+		} else {
+			err = db.UpdateImport(db.Database, &im.result.Import)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = im.run(text, parser)
+
+	// hash whatever the parser left unread
+	if _, copyErr := io.Copy(io.Discard, br); err == nil && copyErr != nil {
+		err = fmt.Errorf("%w: %w", ErrUnreadable, copyErr)
+	}
+
+	im.result.Import.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if err == nil {
+		err = im.check()
+	}
+
+	if err == nil && !opts.DryRun {
+		err = im.commit()
+	}
+
+	if im.tx != nil {
+		im.tx.Rollback()
+		im.tx = nil
+	}
+
+	if opts.DryRun {
+		return im.result, err
+	}
+
+	return im.finish(err)
+}
+
+// finish records the outcome of the import.
+func (im *importer) finish(err error) (*Result, error) {
+	imp := &im.result.Import
+
+	if err != nil {
+		imp.Status = models.ImportFailed
+	} else {
+		imp.Status = models.ImportCompleted
+	}
+
+	if updateErr := db.UpdateImport(db.Database, imp); updateErr != nil && err == nil {
+		err = updateErr
+	}
+
+	return im.result, err
+}
+
+func (im *importer) run(br *bufio.Reader, parser parsers.StatementParser) error {
+	tx, err := db.Database.BeginTx(im.ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error - failed to start transaction: %w", err)
+	}
+
+	im.tx = tx
+
+	if err := parser.Parse(br, im); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		var dbErr *dbError
+
+		if errors.As(err, &dbErr) {
+			return dbErr.err
+		}
+
+		return fmt.Errorf("%w: %w", ErrUnreadable, err)
+	}
+
+	return im.flush()
+}
+
+// check applies the rules that can only be decided once the whole file was read.
+func (im *importer) check() error {
+	r := im.result
+
+	if r.RejectedCount > 0 && (im.opts.Strict || r.Import.RowCount == 0) {
+		return ErrInvalidLines
+	}
+
+	if r.DuplicateCount > 0 && im.opts.RejectDuplicates {
+		return fmt.Errorf("%w: %d rows", ErrDuplicateRows, r.DuplicateCount)
+	}
+
+	previous, err := db.GetImportByChecksum(db.Database, r.Import.Checksum)
+
+	if err != nil {
+		return err
+	}
+
+	if previous != nil && previous.Id != r.Import.Id {
+		return fmt.Errorf("%w (import %d)", ErrDuplicateFile, previous.Id)
+	}
+
+	sort.Strings(r.NewTitles)
+
+	return nil
+}
+
+func (im *importer) commit() error {
+	if err := db.LinkRefunds(im.tx, im.result.Import.Id); err != nil {
+		return err
+	}
+
+	if err := im.tx.Commit(); err != nil {
+		return fmt.Errorf("error - failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// dbError marks sink failures so they are not reported as unreadable files.
+type dbError struct {
+	err error
+}
+
+func (e *dbError) Error() string {
+	return e.err.Error()
+}
+
+func (im *importer) Add(t models.Transaction) error {
+	im.chunk = append(im.chunk, t)
+
+	if len(im.chunk) < chunkSize {
+		return nil
+	}
+
+	if err := im.flush(); err != nil {
+		return &dbError{err}
+	}
+
+	return nil
+}
+
+func (im *importer) Reject(e models.LineError) error {
+	im.result.RejectedCount++
+
+	if len(im.result.Rejected) < reportLimit {
+		im.result.Rejected = append(im.result.Rejected, e)
+	}
+
+	return nil
+}
+
+// flush writes the pending chunk: duplicates are dropped, titles are matched
+// to expenses, new expenses are created and the rows are copied.
+func (im *importer) flush() error {
+	if len(im.chunk) == 0 {
+		return nil
+	}
+
+	defer func() {
+		im.chunk = im.chunk[:0]
+	}()
+
+	if err := im.ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := im.checkCurrency(im.chunk); err != nil {
+		return err
+	}
+
+	setFingerprints(im.chunk, &im.seen)
+
+	fresh, err := im.skipDuplicates(im.chunk)
+
+	if err != nil {
+		return err
+	}
+
+	if err := im.resolveExpenses(fresh); err != nil {
+		return err
+	}
+
+	r := im.result
+
+	for i, t := range fresh {
+		e := r.Expenses[t.Title]
+
+		fresh[i].ExpenseId = e.Id
+		fresh[i].ImportId = r.Import.Id
+
+		if t.Kind != models.KindPayment && t.Kind != models.KindIncome {
+			r.Import.Total = r.Import.Total.Add(t.Value)
+		}
+
+		if im.opts.DryRun && len(r.Rows) < reportLimit {
+			r.Rows = append(r.Rows, PreviewRow{
+				Transaction: fresh[i],
+				Category:    e.Category,
+				NewTitle:    e.Id == 0,
+			})
+		}
+	}
+
+	r.Import.RowCount += len(fresh)
+
+	if !im.opts.DryRun {
+		if err := db.CopyTransactions(im.ctx, im.tx, fresh); err != nil {
+			return err
+		}
+	}
+
+	if im.progress != nil {
+		im.progress(r)
+	}
+
+	im.emit(EventProgress, ProgressEvent{
+		RowsProcessed:  r.Import.RowCount,
+		RejectedCount:  r.RejectedCount,
+		DuplicateCount: r.DuplicateCount,
+	})
+
+	return nil
+}
+
+// checkCurrency refuses rows in another currency than the first one, as the
+// import total and the expense sums are kept in one currency.
+func (im *importer) checkCurrency(ts []models.Transaction) error {
+	for _, t := range ts {
+		if im.currency == "" {
+			im.currency = t.Value.Currency
+			im.result.Import.Total.Currency = t.Value.Currency
+		}
+
+		if t.Value.Currency != im.currency {
+			return fmt.Errorf("%w: %s and %s", ErrMixedCurrency, im.currency, t.Value.Currency)
+		}
+	}
+
+	return nil
+}
+
+func (im *importer) skipDuplicates(ts []models.Transaction) ([]models.Transaction, error) {
+	fps := make([]string, len(ts))
+
+	for i, t := range ts {
+		fps[i] = t.Fingerprint
+	}
+
+	existing, err := db.GetExistingFingerprints(im.tx, fps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := ts[:0:0]
+
+	// a fingerprint repeated within the chunk would fail the whole COPY
+	inChunk := make(map[string]bool, len(ts))
+
+	for _, t := range ts {
+		if !existing[t.Fingerprint] && !inChunk[t.Fingerprint] {
+			inChunk[t.Fingerprint] = true
+			fresh = append(fresh, t)
+			continue
+		}
+
+		im.result.DuplicateCount++
+
+		if len(im.result.Duplicates) < reportLimit {
+			im.result.Duplicates = append(im.result.Duplicates, t)
+		}
+	}
+
+	return fresh, nil
+}
+
+// resolveExpenses looks up every title not seen before in this import and
+// creates the missing ones, categorized by the first rule matching them, like
+// the most similar existing expense, by the classifier when it is confident
+// enough, or under the default category.
+func (im *importer) resolveExpenses(ts []models.Transaction) error {
+	r := im.result
+	pending := make(map[string]models.Expense)
+	amounts := make(map[string]models.Money)
+
+	for _, t := range ts {
+		e, ok := r.Expenses[t.Title]
+
+		if !ok {
+			found, err := db.GetExpenseByTitle(im.tx, t.Title)
+
+			if err != nil {
+				return err
+			}
+
+			if found != nil {
+				e = *found
+
+				im.emit(EventCategory, CategoryEvent{Title: e.Title, Category: e.Category})
+			} else {
+				e = models.Expense{Title: t.Title, Active: true}
+				pending[t.Title] = e
+				amounts[t.Title] = t.Value
+				r.NewTitles = append(r.NewTitles, t.Title)
+
+				im.emit(EventTitle, t.Title)
+			}
+		}
+
+		e.Value = e.Value.Add(t.Value)
+		r.Expenses[t.Title] = e
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := im.categorize(pending, amounts); err != nil {
+		return err
+	}
+
+	if im.opts.DryRun {
+		return im.previewCategory(pending)
+	}
+
+	if err := db.SaveExpensesBatch(im.ctx, im.tx, pending, r.Import.Id); err != nil {
+		return err
+	}
+
+	for title, p := range pending {
+		e := r.Expenses[title]
+		e.Id = p.Id
+		e.Category = p.Category
+		e.CategoryId = p.CategoryId
+		e.CategorySource = p.CategorySource
+		e.Confidence = p.Confidence
+		e.NeedsReview = p.NeedsReview
+		e.SuggestedCategoryId = p.SuggestedCategoryId
+		r.Expenses[title] = e
+
+		im.emit(EventCategory, CategoryEvent{
+			Title:       title,
+			Category:    e.Category,
+			NewTitle:    true,
+			RuleId:      im.ruleIds[title],
+			Confidence:  p.Confidence,
+			NeedsReview: p.NeedsReview,
+		})
+	}
+
+	return nil
+}
+
+// categorize picks the category of new titles from the first rule matching
+// the title and the amount it first appeared with, then from the closest
+// existing title, then from the classifier. Unsure predictions are only kept
+// as suggestions and flagged for review. Rules, titles and the classifier are
+// loaded once per import.
+func (im *importer) categorize(pending map[string]models.Expense, amounts map[string]models.Money) error {
+	if err := im.loadCategorizers(); err != nil {
+		return err
+	}
+
+	for title, e := range pending {
+		if rule := im.rules.Match(title, amounts[title]); rule != nil {
+			e.CategoryId = rule.CategoryId
+			e.Category = rule.Category
+			e.CategorySource = models.CategoryRule
+			pending[title] = e
+			im.ruleIds[title] = rule.Id
+			continue
+		}
+
+		if m, ok := im.titles.Closest(title, im.fuzzyThreshold); ok {
+			e.CategoryId = m.CategoryId
+			e.Category = m.Category
+			e.CategorySource = models.CategoryFuzzy
+			e.Confidence = m.Score
+			pending[title] = e
+
+			if len(im.result.FuzzyMatches) < reportLimit {
+				im.result.FuzzyMatches = append(im.result.FuzzyMatches, m)
+			}
+
+			continue
+		}
+
+		p, ok := im.classifier.Predict(title, amounts[title])
+
+		if !ok {
+			continue
+		}
+
+		e.Confidence = p.Confidence
+
+		if p.Confidence >= im.minConfidence && im.classifier.Examples() >= im.minExamples {
+			e.CategoryId = p.CategoryId
+			e.Category = p.Category
+			e.CategorySource = models.CategoryClassifier
+		} else {
+			e.NeedsReview = true
+			e.SuggestedCategoryId = p.CategoryId
+		}
+
+		pending[title] = e
+	}
+
+	return nil
+}
+
+func (im *importer) loadCategorizers() error {
+	if im.classifier == nil {
+		examples, err := db.GetTrainingExpenses(im.tx)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to get categorized expenses: %w", err)
+		}
+
+		im.classifier = categorizer.Train(examples)
+	}
+
+	if im.titles == nil {
+		expenses, err := db.GetCategorizedExpenses(im.tx)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to get categorized expenses: %w", err)
+		}
+
+		im.titles = categorizer.NewIndex(expenses)
+	}
+
+	if im.rules == nil {
+		all, err := db.GetAllRules(im.tx)
+
+		if err != nil {
+			return fmt.Errorf("error - failed to get categorization rules: %w", err)
+		}
+
+		if im.rules, err = categorizer.Compile(all); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envFloat reads a ratio between 0 and 1, such as how sure the classifier
+// must be for its category to be applied without review.
+func envFloat(name string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(name), 64)
+
+	if err != nil || f <= 0 || f > 1 {
+		return fallback
+	}
+
+	return f
+}
+
+func (im *importer) emit(kind string, data any) {
+	if im.opts.DryRun {
+		return
+	}
+
+	publish(im.result.Import.Id, Event{Type: kind, Data: data})
+}
+
+// previewCategory gives new titles the category SaveExpensesBatch would use.
+func (im *importer) previewCategory(pending map[string]models.Expense) error {
+	for title, p := range pending {
+		e := im.result.Expenses[title]
+		e.Category = p.Category
+		e.CategoryId = p.CategoryId
+		e.CategorySource = p.CategorySource
+		e.Confidence = p.Confidence
+		e.NeedsReview = p.NeedsReview
+		e.SuggestedCategoryId = p.SuggestedCategoryId
+		im.result.Expenses[title] = e
+	}
+
+	if im.category == nil {
+		c, err := db.GetCategoryByName(im.tx, "Outros")
+
+		if err != nil {
+			return fmt.Errorf("%w: %w", errDefaultMissing, err)
+		}
+
+		im.category = c
+	}
+
+	for title := range pending {
+		e := im.result.Expenses[title]
+
+		if e.CategoryId != 0 {
+			continue
+		}
+
+		e.Category = im.category.Name
+		e.CategoryId = im.category.Id
+		e.CategorySource = models.CategoryDefault
+		im.result.Expenses[title] = e
+	}
+
+	return nil
+}
+
+// occurrenceDates bounds how many dates keep their row counts. Statements
+// mostly list rows by date, so only dates unused for this many other dates are
+// forgotten and memory stays flat.
+const occurrenceDates = 400
+
+// occurrences counts identical rows per date.
+type occurrences struct {
+	dates map[string]*dateOccurrences
+	tick  int
+}
+
+type dateOccurrences struct {
+	used   int
+	counts map[string]int
+}
+
+func (o *occurrences) next(date, key string) int {
+	if o.dates == nil {
+		o.dates = make(map[string]*dateOccurrences)
+	}
+
+	o.tick++
+
+	d, ok := o.dates[date]
+
+	if !ok {
+		if len(o.dates) >= occurrenceDates {
+			o.forgetOldest()
+		}
+
+		d = &dateOccurrences{counts: make(map[string]int)}
+		o.dates[date] = d
+	}
+
+	d.used = o.tick
+	d.counts[key]++
+
+	return d.counts[key]
+}
+
+// forgetOldest drops the date used least recently.
+func (o *occurrences) forgetOldest() {
+	var oldest string
+	used := -1
+
+	for date, d := range o.dates {
+		if used < 0 || d.used < used {
+			oldest, used = date, d.used
+		}
+	}
+
+	delete(o.dates, oldest)
+}
+
+// setFingerprints identifies each row by date, description and amount. The
+// occurrence index keeps identical purchases made on the same day apart. Rows
+// carrying a bank transaction id use it instead.
+func setFingerprints(ts []models.Transaction, seen *occurrences) {
+	for i, t := range ts {
+		if t.ExternalId != "" {
+			sum := sha256.Sum256([]byte("id|" + t.ExternalId))
+			ts[i].Fingerprint = hex.EncodeToString(sum[:])
+			continue
+		}
+
+		date := t.Date.Format(time.DateOnly)
+		key := date + "|" + t.RawTitle + "|" + t.Value.String()
+
+		n := seen.next(date, key)
+
+		sum := sha256.Sum256([]byte(key + "|" + strconv.Itoa(n)))
+		ts[i].Fingerprint = hex.EncodeToString(sum[:])
+	}
+}
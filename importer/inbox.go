@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"csv_extractor/models"
+)
+
+const (
+	processedDir = "processed"
+	failedDir    = "failed"
+)
+
+// inboxReport is written next to a moved file when its import failed or
+// rejected lines.
+type inboxReport struct {
+	File     string
+	Import   *models.Import
+	Error    string
+	Rejected []models.LineError
+}
+
+// StartInboxWatcher imports every statement dropped into INBOX_DIR, polling
+// every INBOX_POLL_SECONDS. It does nothing when INBOX_DIR is not set.
+func StartInboxWatcher(ctx context.Context) error {
+	dir := os.Getenv("INBOX_DIR")
+
+	if dir == "" {
+		return nil
+	}
+
+	for _, sub := range []string{processedDir, failedDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	interval := time.Duration(envInt("INBOX_POLL_SECONDS", 10)) * time.Second
+
+	go watchInbox(ctx, dir, interval)
+
+	return nil
+}
+
+func watchInbox(ctx context.Context, dir string, interval time.Duration) {
+	// a file is only picked up once its size held still for a whole poll, so
+	// copies still in progress are left alone
+	sizes := make(map[string]int64)
+
+	for {
+		entries, err := os.ReadDir(dir)
+
+		if err != nil {
+			fmt.Println("Inbox error:", err)
+		}
+
+		seen := make(map[string]int64)
+
+		for _, e := range entries {
+			name := e.Name()
+
+			if e.IsDir() || strings.HasPrefix(name, ".") || !Accepted(name, "") {
+				continue
+			}
+
+			info, err := e.Info()
+
+			if err != nil {
+				continue
+			}
+
+			if last, ok := sizes[name]; !ok || last != info.Size() {
+				seen[name] = info.Size()
+				continue
+			}
+
+			importInboxFile(ctx, dir, name)
+		}
+
+		sizes = seen
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// importInboxFile runs a dropped file through the upload pipeline and moves it
+// out of the inbox, whatever the outcome.
+func importInboxFile(ctx context.Context, dir, name string) {
+	path := filepath.Join(dir, name)
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		fmt.Println("Inbox error:", err)
+		return
+	}
+
+	result, err := runInboxFile(ctx, f, name)
+	f.Close()
+
+	// stopping midway leaves the file for the next start
+	if ctx.Err() != nil {
+		return
+	}
+
+	report := inboxReport{File: name}
+
+	if result != nil {
+		report.Import = &result.Import
+		report.Rejected = result.Rejected
+	}
+
+	dest := processedDir
+
+	if err != nil {
+		dest = failedDir
+		report.Error = err.Error()
+	}
+
+	moved, err := moveUnique(path, filepath.Join(dir, dest))
+
+	if err != nil {
+		fmt.Println("Inbox error:", err)
+		return
+	}
+
+	if report.Error == "" && len(report.Rejected) == 0 {
+		return
+	}
+
+	if err := writeReport(moved+".report.json", report); err != nil {
+		fmt.Println("Inbox error:", err)
+	}
+}
+
+// runInboxFile is Run for a dropped file. A malformed file fails its own
+// import rather than stopping the watcher, and is moved to failed like any
+// other failure.
+func runInboxFile(ctx context.Context, f *os.File, name string) (result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("error - import crashed: %v", r)
+		}
+	}()
+
+	return Run(ctx, f, name, Options{})
+}
+
+// moveUnique moves a file into dir, adding a timestamp to its name when a file
+// of the same name was moved there before.
+func moveUnique(path, dir string) (string, error) {
+	name := filepath.Base(path)
+	dest := filepath.Join(dir, name)
+
+	if _, err := os.Stat(dest); err == nil {
+		ext := filepath.Ext(name)
+		dest = filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+time.Now().Format("20060102150405")+ext)
+	}
+
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+
+	return dest, nil
+}
+
+func writeReport(path string, report inboxReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,285 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"csv_extractor/db"
+	"csv_extractor/models"
+)
+
+// pollInterval is how often idle workers look for jobs nobody woke them for.
+const pollInterval = 5 * time.Second
+
+// progressInterval throttles progress writes to the database.
+const progressInterval = time.Second
+
+var wake = make(chan struct{}, 1)
+
+func envInt(name string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+
+	if err != nil || n <= 0 {
+		return fallback
+	}
+
+	return n
+}
+
+func spoolDir() string {
+	if dir := os.Getenv("IMPORT_SPOOL_DIR"); dir != "" {
+		return dir
+	}
+
+	return filepath.Join(os.TempDir(), "csv_extractor-imports")
+}
+
+// StartWorkers queues again the jobs a previous run left unfinished and starts
+// IMPORT_WORKERS background workers.
+func StartWorkers(ctx context.Context) error {
+	if err := db.RequeueInterruptedImports(db.Database); err != nil {
+		return err
+	}
+
+	for i := 0; i < envInt("IMPORT_WORKERS", 2); i++ {
+		go worker(ctx)
+	}
+
+	notify()
+
+	return nil
+}
+
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue spools the statement to disk and queues it for the workers.
+func Enqueue(src io.Reader, fileName string, opts Options) (*models.Import, error) {
+	dir := spoolDir()
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error - failed to create spool directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "import-*.upload")
+
+	if err != nil {
+		return nil, fmt.Errorf("error - failed to spool upload: %w", err)
+	}
+
+	n, err := io.Copy(f, src)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	options, err := json.Marshal(opts)
+
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	imp := models.Import{FileName: fileName}
+	job := models.ImportJob{
+		FilePath:   f.Name(),
+		Options:    options,
+		BytesTotal: n,
+	}
+
+	if err := db.QueueImport(db.Database, &imp, &job); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	notify()
+
+	return &imp, nil
+}
+
+func worker(ctx context.Context) {
+	for {
+		job, err := db.ClaimImportJob(db.Database)
+
+		if err != nil {
+			fmt.Println("Import job error:", err)
+		}
+
+		if job != nil {
+			// another worker may pick the next queued job meanwhile
+			notify()
+			process(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+
+	return n, err
+}
+
+func process(ctx context.Context, job *models.ImportJob) {
+	err := runJob(ctx, job)
+
+	if err != nil {
+		job.Error = err.Error()
+	}
+
+	if err := db.FinishImportJob(db.Database, job); err != nil {
+		fmt.Println("Import job error:", err)
+	}
+
+	// subscribers read the summary from the stored status once closed, so the
+	// job's error and rejected lines must be stored first
+	closeSubscribers(job.ImportId)
+
+	os.Remove(job.FilePath)
+}
+
+func runJob(ctx context.Context, job *models.ImportJob) (err error) {
+	imp, err := db.GetImportById(db.Database, job.ImportId)
+
+	if err != nil {
+		return err
+	}
+
+	fail := func(err error) error {
+		imp.Status = models.ImportFailed
+
+		if updateErr := db.UpdateImport(db.Database, imp); updateErr != nil {
+			fmt.Println("Import status error:", updateErr)
+		}
+
+		return err
+	}
+
+	// a malformed file must fail its own import, not stop the server
+	defer func() {
+		if r := recover(); r != nil {
+			err = fail(fmt.Errorf("error - import crashed: %v", r))
+		}
+	}()
+
+	var opts Options
+
+	if err := json.Unmarshal(job.Options, &opts); err != nil {
+		return fail(err)
+	}
+
+	f, err := os.Open(job.FilePath)
+
+	if err != nil {
+		return fail(err)
+	}
+
+	defer f.Close()
+
+	src := &countingReader{r: f}
+	last := time.Now()
+
+	result, err := RunImport(ctx, src, imp, opts, func(r *Result) {
+		job.BytesRead = src.n.Load()
+		job.RowsProcessed = r.Import.RowCount
+		job.RejectedCount = r.RejectedCount
+
+		if time.Since(last) < progressInterval {
+			return
+		}
+
+		last = time.Now()
+
+		if err := db.UpdateImportJobProgress(db.Database, job); err != nil {
+			fmt.Println("Import job error:", err)
+		}
+	})
+
+	job.BytesRead = src.n.Load()
+
+	if result != nil {
+		job.RowsProcessed = result.Import.RowCount
+		job.RejectedCount = result.RejectedCount
+		job.Rejected = result.Rejected
+	}
+
+	return err
+}
+
+// Status reports how far an import got. Imports processed inline only have
+// their final counts.
+func Status(importId int) (*models.ImportStatus, error) {
+	imp, err := db.GetImportById(db.Database, importId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.ImportStatus{
+		ImportId:      imp.Id,
+		Status:        imp.Status,
+		RowsProcessed: imp.RowCount,
+	}
+
+	if imp.Status == models.ImportCompleted {
+		status.Progress = 1
+	}
+
+	job, err := db.GetImportJob(db.Database, importId)
+
+	if err != nil || job == nil {
+		return &status, err
+	}
+
+	// the job's count lags behind by up to progressInterval until it finishes
+	if imp.Status != models.ImportCompleted && imp.Status != models.ImportFailed {
+		status.RowsProcessed = job.RowsProcessed
+	}
+
+	status.RejectedCount = job.RejectedCount
+	status.Rejected = job.Rejected
+	status.BytesRead = job.BytesRead
+	status.BytesTotal = job.BytesTotal
+	status.Error = job.Error
+
+	if job.BytesTotal > 0 && imp.Status != models.ImportCompleted {
+		status.Progress = float64(job.BytesRead) / float64(job.BytesTotal)
+	}
+
+	// assumes the rest of the file is read at the pace seen so far
+	if imp.Status == models.ImportProcessing && job.BytesRead > 0 && !job.StartedAt.IsZero() {
+		elapsed := time.Since(job.StartedAt).Seconds()
+		status.EtaSeconds = elapsed * float64(job.BytesTotal-job.BytesRead) / float64(job.BytesRead)
+	}
+
+	return &status, nil
+}
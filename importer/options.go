@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"bufio"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"csv_extractor/db"
+	"csv_extractor/parsers"
+)
+
+type Options struct {
+	// Format and Profile pick the parser; the format is detected when both
+	// are empty. Sheet selects the spreadsheet tab of xlsx files.
+	Format  string
+	Profile string
+	Sheet   string
+	// Encoding and Delimiter override what is detected from the file.
+	Encoding  string
+	Delimiter string
+	// Strict imports nothing when any line is rejected.
+	Strict bool
+	// RejectDuplicates fails the import instead of skipping rows that were
+	// already imported.
+	RejectDuplicates bool
+	// DryRun reports what the import would do without writing anything.
+	DryRun bool
+}
+
+func OptionsFromValues(v url.Values) Options {
+	strict, _ := strconv.ParseBool(v.Get("strict"))
+	dryRun, _ := strconv.ParseBool(v.Get("dry_run"))
+
+	return Options{
+		Format:           v.Get("format"),
+		Profile:          v.Get("profile"),
+		Sheet:            v.Get("sheet"),
+		Encoding:         v.Get("encoding"),
+		Delimiter:        v.Get("delimiter"),
+		Strict:           strict,
+		RejectDuplicates: v.Get("duplicates") == "reject",
+		DryRun:           dryRun,
+	}
+}
+
+var uploadTypes = map[string]bool{
+	"text/csv":                 true,
+	"application/x-ofx":        true,
+	"application/ofx":          true,
+	"application/vnd.intu.qfx": true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": true,
+}
+
+var uploadExtensions = map[string]bool{
+	".csv":  true,
+	".ofx":  true,
+	".qfx":  true,
+	".xlsx": true,
+}
+
+// Accepted checks the declared type, falling back to the extension as
+// clients rarely agree on a content type for ofx files.
+func Accepted(fileName, contentType string) bool {
+	ct, _, _ := mime.ParseMediaType(contentType)
+
+	return uploadTypes[ct] || uploadExtensions[strings.ToLower(filepath.Ext(fileName))]
+}
+
+// SelectParser uses the requested csv profile or format or, when neither is
+// given, detects the format from the beginning of the file. Spreadsheets are
+// read from the given sheet and mapped with the same profiles.
+func SelectParser(br *bufio.Reader, opts Options) (parsers.StatementParser, error) {
+	var p parsers.StatementParser
+	var comma rune
+
+	if opts.Delimiter != "" {
+		var err error
+
+		if comma, err = parsers.ParseDelimiter(opts.Delimiter); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case opts.Profile != "":
+		cp, err := db.GetCsvProfileByName(db.Database, opts.Profile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p, err = parsers.FromCsvProfile(*cp); err != nil {
+			return nil, err
+		}
+	case opts.Format != "":
+		var err error
+
+		if p, err = parsers.Get(opts.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	head, _ := br.Peek(4096)
+
+	if parsers.IsXlsx(head) {
+		return parsers.NewXlsx(opts.Sheet, p)
+	}
+
+	if p == nil {
+		return parsers.Detect(head, comma)
+	}
+
+	if d, ok := p.(parsers.Delimited); ok && comma != 0 {
+		return d.WithDelimiter(comma), nil
+	}
+
+	return p, nil
+}
+
+// decode transcodes text statements to UTF-8. Spreadsheets are binary and
+// left untouched.
+func decode(br *bufio.Reader, encoding string) (*bufio.Reader, error) {
+	head, _ := br.Peek(4096)
+
+	if parsers.IsXlsx(head) {
+		return br, nil
+	}
+
+	r, err := parsers.Decode(br, encoding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bufio.NewReader(r), nil
+}
@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"csv_extractor/db"
 	"csv_extractor/handlers"
+	"csv_extractor/importer"
+	"csv_extractor/parsers"
 )
 
 func main() {
 	http.HandleFunc("GET /healthcheck", handlers.HealthCheckHandler)
 	http.HandleFunc("GET /categories", handlers.GetCategories)
+	http.HandleFunc("GET /categories/tree", handlers.GetCategoryTree)
 	http.HandleFunc("POST /category", handlers.SaveCategory)
 	http.HandleFunc("PUT /category", handlers.UpdateCategory)
 	http.HandleFunc("DELETE /category/{id}", handlers.DisableCategory)
@@ -19,7 +23,29 @@ func main() {
 	http.HandleFunc("POST /expense", handlers.SaveExpense)
 	http.HandleFunc("PUT /expense", handlers.UpdateExpense)
 	http.HandleFunc("DELETE /expense/{id}", handlers.DisableExpense)
+	http.HandleFunc("GET /expenses/{id}/aliases", handlers.GetMerchantAliases)
+	http.HandleFunc("POST /expenses/{id}/merge", handlers.MergeExpenses)
+	http.HandleFunc("GET /review", handlers.GetReviewQueue)
+	http.HandleFunc("POST /review/decisions", handlers.SaveReviewDecisions)
+	http.HandleFunc("GET /transactions", handlers.GetTransactions)
+	http.HandleFunc("GET /installments", handlers.GetInstallments)
+	http.HandleFunc("GET /reports/categories", handlers.GetCategoryReport)
+	http.HandleFunc("GET /exchange-rates", handlers.GetExchangeRates)
+	http.HandleFunc("POST /exchange-rates/import", handlers.ImportExchangeRates)
+	http.HandleFunc("GET /formats", handlers.GetFormats)
+	http.HandleFunc("GET /csv-profiles", handlers.GetCsvProfiles)
+	http.HandleFunc("POST /csv-profiles", handlers.SaveCsvProfile)
+	http.HandleFunc("DELETE /csv-profiles/{id}", handlers.DeleteCsvProfile)
+	http.HandleFunc("GET /categorization-rules", handlers.GetRules)
+	http.HandleFunc("POST /categorization-rules", handlers.SaveRule)
+	http.HandleFunc("PUT /categorization-rules/{id}", handlers.UpdateRule)
+	http.HandleFunc("DELETE /categorization-rules/{id}", handlers.DeleteRule)
 	http.HandleFunc("POST /upload", handlers.CsvUploadHandler)
+	http.HandleFunc("GET /imports", handlers.GetImports)
+	http.HandleFunc("GET /imports/{id}", handlers.GetImport)
+	http.HandleFunc("GET /imports/{id}/status", handlers.GetImportStatus)
+	http.HandleFunc("GET /imports/{id}/events", handlers.GetImportEvents)
+	http.HandleFunc("DELETE /imports/{id}", handlers.RevertImport)
 
 	err := db.Connect()
 
@@ -33,6 +59,31 @@ func main() {
 
 	defer db.Database.Close()
 
+	err = db.Migrate(db.Database)
+
+	if err != nil {
+		log.Fatal("error - failed database migration: ", err)
+	}
+
+	// titles stored before merchant names were normalized
+	err = db.AliasNormalizedTitles(db.Database, parsers.NormalizeMerchant)
+
+	if err != nil {
+		log.Fatal("error - failed database migration: ", err)
+	}
+
+	err = importer.StartWorkers(context.Background())
+
+	if err != nil {
+		log.Fatal("error - failed to start import workers: ", err)
+	}
+
+	err = importer.StartInboxWatcher(context.Background())
+
+	if err != nil {
+		log.Fatal("error - failed to start inbox watcher: ", err)
+	}
+
 	fmt.Println("Server is running at http://localhost:3000")
 	log.Fatal(http.ListenAndServe(":3000", nil))
 }
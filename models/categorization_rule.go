@@ -0,0 +1,22 @@
+package models
+
+const (
+	RuleContains = "contains"
+	RulePrefix   = "prefix"
+	RuleRegex    = "regex"
+)
+
+// CategorizationRule puts new titles matching it into CategoryId. A rule
+// matches on its title condition, its amount range or both; either may be
+// left empty. Rules with a higher Priority are tried first.
+type CategorizationRule struct {
+	Id         int
+	MatchType  string
+	Pattern    string
+	MinAmount  *Money
+	MaxAmount  *Money
+	CategoryId int
+	Category   string
+	Priority   int
+	Active     bool
+}
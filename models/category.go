@@ -1,7 +1,108 @@
 package models
 
+import (
+	"sort"
+	"strings"
+)
+
+// Category may sit under a parent, as in "Alimentação > Restaurantes >
+// Delivery". ParentId is zero for top level categories.
 type Category struct {
-	Id     int
-	Name   string
-	Active bool
+	Id       int
+	Name     string
+	Active   bool
+	ParentId int
+}
+
+// CategoryTree finds categories and their ancestors by id.
+type CategoryTree map[int]Category
+
+func NewCategoryTree(categories []Category) CategoryTree {
+	tree := make(CategoryTree, len(categories))
+
+	for _, c := range categories {
+		tree[c.Id] = c
+	}
+
+	return tree
+}
+
+// Path lists a category's ancestors from the top level down to itself.
+func (t CategoryTree) Path(id int) []Category {
+	var path []Category
+
+	// the length bound guards against cycles in inconsistent data
+	for c, ok := t[id]; ok && len(path) <= len(t); c, ok = t[c.ParentId] {
+		path = append([]Category{c}, path...)
+	}
+
+	return path
+}
+
+// RollUp returns the ancestor of a category at the given level, 1 being the
+// top level. Level 0, or one deeper than the category, returns the category.
+func (t CategoryTree) RollUp(id, level int) (Category, bool) {
+	path := t.Path(id)
+
+	if len(path) == 0 {
+		return Category{}, false
+	}
+
+	if level <= 0 || level > len(path) {
+		return path[len(path)-1], true
+	}
+
+	return path[level-1], true
+}
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	Category
+	// Path names the category under its ancestors, as in "Alimentação >
+	// Restaurantes".
+	Path     string
+	Children []CategoryNode
+}
+
+// Nodes nests every category under its parent, top level categories first.
+func (t CategoryTree) Nodes() []CategoryNode {
+	children := make(map[int][]Category)
+
+	for _, c := range t {
+		children[c.ParentId] = append(children[c.ParentId], c)
+	}
+
+	return t.nodes(children, 0, len(t))
+}
+
+// nodes builds the subtree under parent; depth bounds it should the data hold
+// a cycle.
+func (t CategoryTree) nodes(children map[int][]Category, parent, depth int) []CategoryNode {
+	if depth < 0 {
+		return nil
+	}
+
+	list := children[parent]
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	nodes := make([]CategoryNode, 0, len(list))
+
+	for _, c := range list {
+		var names []string
+
+		for _, a := range t.Path(c.Id) {
+			names = append(names, a.Name)
+		}
+
+		nodes = append(nodes, CategoryNode{
+			Category: c,
+			Path:     strings.Join(names, " > "),
+			Children: t.nodes(children, c.Id, depth-1),
+		})
+	}
+
+	return nodes
 }
@@ -0,0 +1,23 @@
+package models
+
+// CsvProfile is a user maintained column mapping. Columns are zero based; a
+// profile uses either AmountColumn or the DebitColumn/CreditColumn pair.
+// Locale ("pt-BR" or "en-US") supplies the decimal separator and date layouts
+// the profile leaves empty. Currency is the account currency, BRL when empty.
+type CsvProfile struct {
+	Id               int
+	Name             string
+	Delimiter        string
+	SkipRows         int
+	DateColumn       int
+	TitleColumn      int
+	AmountColumn     *int
+	DebitColumn      *int
+	CreditColumn     *int
+	DateLayout       string
+	DecimalSeparator string
+	Locale           string
+	Currency         string
+	Negate           bool
+	IgnoreTitles     []string
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ExchangeRate says one unit of From was worth Rate units of To on Date.
+type ExchangeRate struct {
+	Date time.Time
+	From string
+	To   string
+	Rate float64
+}
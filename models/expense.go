@@ -1,10 +1,26 @@
 package models
 
+const (
+	CategoryManual     = "manual"
+	CategoryRule       = "rule"
+	CategoryClassifier = "classifier"
+	CategoryFuzzy      = "fuzzy"
+	CategoryDefault    = "default"
+)
+
 type Expense struct {
 	Id         int
 	Title      string
 	Category   string
 	CategoryId int
-	Value      float64
+	Value      Money
 	Active     bool
+	// CategorySource tells how the category was picked. Confidence is the
+	// classifier's probability or the fuzzy match score, and
+	// SuggestedCategoryId the classifier's guess when it was too unsure to
+	// apply it and the expense was left for review.
+	CategorySource      string
+	Confidence          float64
+	NeedsReview         bool
+	SuggestedCategoryId int
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	ImportQueued     = "queued"
+	ImportProcessing = "processing"
+	ImportCompleted  = "completed"
+	ImportFailed     = "failed"
+	ImportReverted   = "reverted"
+)
+
+// Import is one uploaded statement. Total sums its spending in the statement
+// currency; statements mixing currencies are refused.
+type Import struct {
+	Id         int
+	FileName   string
+	Checksum   string
+	UploadedAt time.Time
+	RowCount   int
+	Total      Money
+	Status     string
+}
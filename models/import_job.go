@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ImportJob is the persisted state of an import processed in the background.
+// Options holds the importer options as JSON.
+type ImportJob struct {
+	ImportId      int
+	FilePath      string
+	Options       []byte
+	BytesTotal    int64
+	BytesRead     int64
+	RowsProcessed int
+	RejectedCount int
+	Rejected      []LineError
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	Error         string
+}
+
+// ImportStatus reports the progress of an import. EtaSeconds is only known
+// while a background job is running.
+type ImportStatus struct {
+	ImportId      int
+	Status        string
+	RowsProcessed int
+	RejectedCount int
+	Rejected      []LineError
+	BytesRead     int64
+	BytesTotal    int64
+	Progress      float64
+	EtaSeconds    float64
+	Error         string
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// InstallmentPlan summarises a purchase paid in installments from the latest
+// installment seen in the imported statements.
+type InstallmentPlan struct {
+	Group           string
+	Title           string
+	Amount          Money
+	Paid            int
+	Total           int
+	Remaining       int
+	RemainingAmount Money
+	LastDate        time.Time
+}
+
+// MonthlyCommitment totals the installments due in a month, one per currency.
+type MonthlyCommitment struct {
+	Month  string
+	Amount Money
+}
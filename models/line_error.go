@@ -0,0 +1,8 @@
+package models
+
+// LineError describes a statement line that could not be imported.
+type LineError struct {
+	Line   int
+	Raw    string
+	Reason string
+}
@@ -0,0 +1,8 @@
+package models
+
+// MerchantAlias sends a title variant to the expense of its canonical
+// merchant. Aliases are stored in lower case.
+type MerchantAlias struct {
+	Alias     string
+	ExpenseId int
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "BRL"
+
+// Money is an exact amount kept in cents, so sums never drift the way floats
+// do. Arithmetic assumes both sides share a currency; an empty currency takes
+// the other side's.
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+func NewMoney(cents int64, currency string) Money {
+	return Money{Cents: cents, Currency: currency}
+}
+
+// ParseMoney reads a plain decimal such as "-1234.5" or "12.90". Digits past
+// the cents are refused rather than rounded, since they usually mean the
+// separators were misread, as "1,234" read as 1.234.
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+
+	// spreadsheets may store numbers in scientific notation
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+
+		if err != nil {
+			return Money{}, err
+		}
+
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	units, frac, _ := strings.Cut(s, ".")
+
+	if units == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if units == "" {
+		units = "0"
+	}
+
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("amount %q has more than 2 decimal places", s)
+	}
+
+	frac += strings.Repeat("0", 2-len(frac))
+
+	cents, err := strconv.ParseInt(units+frac, 10, 64)
+
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return Money{Cents: cents, Currency: currency}, nil
+}
+
+func (m Money) currency(o Money) string {
+	if m.Currency == "" {
+		return o.Currency
+	}
+
+	return m.Currency
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{Cents: m.Cents + o.Cents, Currency: m.currency(o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Cents: m.Cents - o.Cents, Currency: m.currency(o)}
+}
+
+func (m Money) Mul(n int64) Money {
+	return Money{Cents: m.Cents * n, Currency: m.Currency}
+}
+
+func (m Money) Neg() Money {
+	return Money{Cents: -m.Cents, Currency: m.Currency}
+}
+
+func (m Money) Abs() Money {
+	if m.Cents < 0 {
+		return m.Neg()
+	}
+
+	return m
+}
+
+func (m Money) IsNegative() bool {
+	return m.Cents < 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// String formats the amount as a plain decimal, such as "-1234.50".
+func (m Money) String() string {
+	sign := ""
+	cents := m.Cents
+
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Value stores the amount in NUMERIC columns. The currency lives in its own
+// column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src any) error {
+	var err error
+	var parsed Money
+
+	switch v := src.(type) {
+	case []byte:
+		parsed, err = ParseMoney(string(v), m.Currency)
+	case string:
+		parsed, err = ParseMoney(v, m.Currency)
+	case int64:
+		parsed = Money{Cents: v * 100, Currency: m.Currency}
+	case float64:
+		parsed = Money{Cents: int64(math.Round(v * 100)), Currency: m.Currency}
+	case nil:
+		parsed = Money{Currency: m.Currency}
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if parsed.Currency == "" {
+		parsed.Currency = DefaultCurrency
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+type moneyJSON struct {
+	Amount   json.Number
+	Currency string
+}
+
+// MarshalJSON writes the amount as an exact decimal number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return json.Marshal(moneyJSON{Amount: json.Number(m.String()), Currency: currency})
+}
+
+// UnmarshalJSON accepts the object written by MarshalJSON or a bare number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+
+	if len(data) > 0 && data[0] == '{' {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.UseNumber()
+
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, &v.Amount); err != nil {
+		return errors.New("amount must be a number")
+	}
+
+	if v.Amount == "" {
+		v.Amount = "0"
+	}
+
+	if v.Currency == "" {
+		v.Currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(v.Amount.String(), v.Currency)
+
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
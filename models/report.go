@@ -0,0 +1,19 @@
+package models
+
+// CategoryReport totals a category by transaction kind. Refunds are negative
+// and counted in the category of the purchase they revert, so Total is the
+// net spending. Payments and income are left out. Amounts are converted to Currency;
+// Unconverted counts transactions left out for lack of an exchange rate.
+// Path names the category under its ancestors, as in "Alimentação >
+// Restaurantes".
+type CategoryReport struct {
+	Category    string
+	Path        string
+	Currency    string
+	Purchases   Money
+	Refunds     Money
+	Fees        Money
+	Interest    Money
+	Total       Money
+	Unconverted int
+}
@@ -0,0 +1,53 @@
+package models
+
+import "regexp"
+
+// ReviewItem is an expense left in the default category, or categorized by the
+// classifier or a fuzzy match with low confidence, with what was spent on it.
+type ReviewItem struct {
+	ExpenseId           int
+	Title               string
+	CategoryId          int
+	Category            string
+	CategorySource      string
+	Confidence          float64
+	SuggestedCategoryId int
+	SuggestedCategory   string
+	Transactions        int
+	Total               Money
+}
+
+// ReviewDecision sets the category of an expense. With CreateRule, a rule
+// sends future titles to the same category; MatchType defaults to contains
+// and Pattern to the expense title.
+type ReviewDecision struct {
+	ExpenseId  int
+	CategoryId int
+	CreateRule bool
+	MatchType  string
+	Pattern    string
+	Priority   int
+}
+
+// Rule is the rule a decision creates for an expense of the given title.
+func (d ReviewDecision) Rule(title string) CategorizationRule {
+	rule := CategorizationRule{
+		MatchType:  d.MatchType,
+		Pattern:    d.Pattern,
+		CategoryId: d.CategoryId,
+		Priority:   d.Priority,
+		Active:     true,
+	}
+
+	if rule.MatchType == "" {
+		rule.MatchType = RuleContains
+	}
+
+	if rule.Pattern == "" && rule.MatchType == RuleRegex {
+		rule.Pattern = "^" + regexp.QuoteMeta(title) + "$"
+	} else if rule.Pattern == "" {
+		rule.Pattern = title
+	}
+
+	return rule
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	KindPurchase = "purchase"
+	KindRefund   = "refund"
+	KindPayment  = "payment"
+	KindFee      = "fee"
+	KindInterest = "interest"
+	// KindIncome is money received that reverts no purchase, such as a
+	// transfer into the account.
+	KindIncome = "income"
+)
+
+type Transaction struct {
+	Id       int
+	Date     time.Time
+	RawTitle string
+	Title    string
+	Value    Money
+	// OriginalValue is what a purchase abroad cost before the card converted
+	// it into Value, nil for purchases in the account currency.
+	OriginalValue *Money
+	ExpenseId     int
+	ImportId      int
+	Fingerprint   string
+	// ExternalId is the bank's own transaction id, such as the OFX FITID.
+	ExternalId string
+	// Installment is the current installment of InstallmentTotal, both zero
+	// for single payments. Installments of one purchase share a group.
+	Installment      int
+	InstallmentTotal int
+	InstallmentGroup string
+	Kind             string
+	// RefundOf points a refund at the purchase it reverts, when one is found.
+	RefundOf int
+}
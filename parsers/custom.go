@@ -0,0 +1,120 @@
+package parsers
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"csv_extractor/models"
+)
+
+var layoutTokens = strings.NewReplacer(
+	"yyyy", "2006",
+	"yy", "06",
+	"MMM", "Jan",
+	"MM", "01",
+	"mm", "01",
+	"dd", "02",
+)
+
+// DateLayout converts patterns such as "dd/mm/yyyy" or "dd MMM" into Go time
+// layouts. Text that already is a Go layout is returned unchanged.
+func DateLayout(pattern string) string {
+	return layoutTokens.Replace(pattern)
+}
+
+// FromCsvProfile builds a parser from a user maintained profile.
+func FromCsvProfile(cp models.CsvProfile) (*ColumnProfile, error) {
+	if err := ValidateCsvProfile(cp); err != nil {
+		return nil, err
+	}
+
+	p := &ColumnProfile{
+		Name:             cp.Name,
+		Comma:            ',',
+		SkipRows:         cp.SkipRows,
+		DateColumn:       cp.DateColumn,
+		TitleColumn:      cp.TitleColumn,
+		DecimalSeparator: cp.DecimalSeparator,
+		Negate:           cp.Negate,
+		IgnoreTitles:     cp.IgnoreTitles,
+	}
+
+	if cp.Currency != "" {
+		p.Currency, _ = CurrencyCode(cp.Currency)
+	}
+
+	if cp.DateLayout != "" {
+		p.DateLayouts = []string{DateLayout(cp.DateLayout)}
+	}
+
+	if cp.Locale != "" {
+		l, _ := GetLocale(cp.Locale)
+
+		if p.DecimalSeparator == "" {
+			p.DecimalSeparator = l.DecimalSeparator
+		}
+
+		p.DateLayouts = append(p.DateLayouts, l.DateLayouts...)
+	}
+
+	if cp.Delimiter != "" {
+		p.Comma, _ = utf8.DecodeRuneInString(cp.Delimiter)
+	}
+
+	if cp.AmountColumn != nil {
+		p.AmountColumn = *cp.AmountColumn
+	} else {
+		p.SplitAmount = true
+		p.DebitColumn = *cp.DebitColumn
+		p.CreditColumn = *cp.CreditColumn
+	}
+
+	return p, nil
+}
+
+func ValidateCsvProfile(cp models.CsvProfile) error {
+	if strings.TrimSpace(cp.Name) == "" {
+		return errors.New("profile name is required")
+	}
+
+	if cp.Locale != "" {
+		if _, err := GetLocale(cp.Locale); err != nil {
+			return err
+		}
+	} else if cp.DateLayout == "" {
+		return errors.New("date layout or locale is required")
+	}
+
+	if cp.Currency != "" {
+		if _, err := CurrencyCode(cp.Currency); err != nil {
+			return err
+		}
+	}
+
+	if utf8.RuneCountInString(cp.Delimiter) > 1 {
+		return errors.New("delimiter must be a single character")
+	}
+
+	if cp.DecimalSeparator != "" && cp.DecimalSeparator != "." && cp.DecimalSeparator != "," {
+		return errors.New(`decimal separator must be "." or ","`)
+	}
+
+	if cp.AmountColumn == nil && (cp.DebitColumn == nil || cp.CreditColumn == nil) {
+		return errors.New("profile needs an amount column or both debit and credit columns")
+	}
+
+	cols := []*int{&cp.DateColumn, &cp.TitleColumn, cp.AmountColumn, cp.DebitColumn, cp.CreditColumn}
+
+	for _, c := range cols {
+		if c != nil && *c < 0 {
+			return errors.New("columns must not be negative")
+		}
+	}
+
+	if cp.SkipRows < 0 {
+		return errors.New("rows to skip must not be negative")
+	}
+
+	return nil
+}
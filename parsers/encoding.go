@@ -0,0 +1,137 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80-0x9F, where Windows-1252 differs from
+// ISO-8859-1. Every other byte is its own code point.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+var utf8Bom = []byte{0xEF, 0xBB, 0xBF}
+
+func singleByteRune(b byte) rune {
+	if b >= 0x80 && b < 0xA0 {
+		return windows1252[b-0x80]
+	}
+
+	return rune(b)
+}
+
+// Decode transcodes a statement to UTF-8 as it is read and drops a UTF-8 byte
+// order mark. With an empty encoding, valid UTF-8 is kept and any other byte
+// is read as Windows-1252, which covers ISO-8859-1 exports and files mixing
+// both. ISO-8859-1 is read as Windows-1252 too, as browsers do.
+func Decode(r io.Reader, encoding string) (io.Reader, error) {
+	d := &decoder{src: bufio.NewReader(r)}
+
+	switch strings.ToLower(strings.ReplaceAll(encoding, "_", "-")) {
+	case "", "auto":
+	case "utf-8", "utf8":
+		d.strict = true
+	case "iso-8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		d.single = true
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	if !d.single {
+		if head, _ := d.src.Peek(len(utf8Bom)); bytes.Equal(head, utf8Bom) {
+			d.src.Discard(len(utf8Bom))
+		}
+	}
+
+	if d.strict {
+		return d.src, nil
+	}
+
+	return d, nil
+}
+
+type decoder struct {
+	src     *bufio.Reader
+	single  bool
+	strict  bool
+	pending []byte
+	buf     []byte
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	if len(d.pending) == 0 {
+		if err := d.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	return n, nil
+}
+
+func (d *decoder) fill() error {
+	in, err := d.src.Peek(d.src.Size())
+
+	if len(in) == 0 {
+		return err
+	}
+
+	out := d.buf[:0]
+	i := 0
+
+	for i < len(in) {
+		c := in[i]
+
+		if c < utf8.RuneSelf {
+			out = append(out, c)
+			i++
+			continue
+		}
+
+		if !d.single {
+			// a sequence cut at the end of the window is decoded on the next fill
+			if !utf8.FullRune(in[i:]) && err == nil && i > 0 {
+				break
+			}
+
+			if r, size := utf8.DecodeRune(in[i:]); r != utf8.RuneError || size > 1 {
+				out = append(out, in[i:i+size]...)
+				i += size
+				continue
+			}
+		}
+
+		out = utf8.AppendRune(out, singleByteRune(c))
+		i++
+	}
+
+	d.src.Discard(i)
+	d.buf = out
+	d.pending = out
+
+	return nil
+}
+
+// ParseDelimiter reads a delimiter override such as ";", "tab" or "\t".
+func ParseDelimiter(s string) (rune, error) {
+	switch s {
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+
+	if size == 0 || size != len(s) || r == utf8.RuneError || r == '"' || r == '\n' || r == '\r' {
+		return 0, fmt.Errorf("invalid delimiter %q", s)
+	}
+
+	return r, nil
+}
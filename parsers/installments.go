@@ -0,0 +1,65 @@
+package parsers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv_extractor/models"
+)
+
+var installmentPattern = regexp.MustCompile(`(?i)\bparc(?:ela)?\.?\s*(\d{1,3})\s*(?:/|de)\s*(\d{1,3})\b`)
+
+// ParseInstallment finds markers such as "Parcela 3/10" or "PARC 03/10".
+func ParseInstallment(raw string) (n, total int, ok bool) {
+	m := installmentPattern.FindStringSubmatch(raw)
+
+	if m == nil {
+		return 0, 0, false
+	}
+
+	n, _ = strconv.Atoi(m[1])
+	total, _ = strconv.Atoi(m[2])
+
+	if n < 1 || total < 2 || n > total {
+		return 0, 0, false
+	}
+
+	return n, total, true
+}
+
+// setInstallment fills the installment fields of a parsed transaction. Every
+// installment of a purchase shares a group derived from the purchase month,
+// which is found by walking back from the current installment, and the amount
+// in whole reais, since the first installment often carries the cent the
+// others could not split.
+func setInstallment(t *models.Transaction) {
+	n, total, ok := ParseInstallment(t.RawTitle)
+
+	if !ok {
+		return
+	}
+
+	t.Installment = n
+	t.InstallmentTotal = total
+	t.Title = strings.TrimSpace(strings.TrimRight(installmentPattern.ReplaceAllString(t.Title, ""), " -"))
+
+	// counted from the first day, as a month before Mar 31 would still be March
+	purchase := time.Date(t.Date.Year(), t.Date.Month()-time.Month(n-1), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+
+	cents := t.Value.Cents
+
+	if cents < 0 {
+		cents = -cents
+	}
+
+	reais := strconv.FormatInt((cents+50)/100, 10)
+
+	key := strings.ToLower(t.Title) + "|" + strconv.Itoa(total) + "|" + purchase + "|" + reais
+
+	sum := sha256.Sum256([]byte(key))
+	t.InstallmentGroup = hex.EncodeToString(sum[:8])
+}
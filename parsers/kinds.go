@@ -0,0 +1,39 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+
+	"csv_extractor/models"
+)
+
+var (
+	paymentPattern  = regexp.MustCompile(`(?i)^(pagamento recebido|pagamento (de|da) fatura|pagamento efetuado|pagto fatura)`)
+	interestPattern = regexp.MustCompile(`(?i)\b(juros|encargos|mora)\b`)
+	feePattern      = regexp.MustCompile(`(?i)\b(iof|tarifa|anuidade|multa)\b`)
+	refundPattern   = regexp.MustCompile(`(?i)^(estorno|reembolso|cr[eé]dito)( de compra| de)?\s*[-:]?\s*`)
+)
+
+// classify sets the transaction kind. Refunds lose their "Estorno de" prefix
+// so they land on the same expense as the purchase they revert. Other credits
+// are income.
+func classify(t *models.Transaction) {
+	switch {
+	case paymentPattern.MatchString(t.Title):
+		t.Kind = models.KindPayment
+	case refundPattern.MatchString(t.Title):
+		t.Kind = models.KindRefund
+
+		if title := refundPattern.ReplaceAllString(t.Title, ""); strings.TrimSpace(title) != "" {
+			t.Title = strings.TrimSpace(title)
+		}
+	case t.Value.IsNegative():
+		t.Kind = models.KindIncome
+	case interestPattern.MatchString(t.Title):
+		t.Kind = models.KindInterest
+	case feePattern.MatchString(t.Title):
+		t.Kind = models.KindFee
+	default:
+		t.Kind = models.KindPurchase
+	}
+}
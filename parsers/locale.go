@@ -0,0 +1,194 @@
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv_extractor/models"
+)
+
+// Locale holds how a region writes numbers and dates. Profiles fill in
+// whatever they don't set themselves from their locale.
+type Locale struct {
+	Name             string
+	DecimalSeparator string
+	DateLayouts      []string
+}
+
+var Locales = map[string]Locale{
+	"pt-BR": {
+		Name:             "pt-BR",
+		DecimalSeparator: ",",
+		DateLayouts:      []string{brDate, "02/01/06", time.DateOnly, "02 Jan 2006", "02 Jan"},
+	},
+	"en-US": {
+		Name:             "en-US",
+		DecimalSeparator: ".",
+		DateLayouts:      []string{"01/02/2006", "01/02/06", time.DateOnly, "Jan 2, 2006", "02 Jan 2006", "02 Jan"},
+	},
+}
+
+func GetLocale(name string) (Locale, error) {
+	for k, l := range Locales {
+		if strings.EqualFold(k, name) {
+			return l, nil
+		}
+	}
+
+	return Locale{}, fmt.Errorf("unknown locale %q", name)
+}
+
+var (
+	currencySymbols = regexp.MustCompile(`(?i)R\$|US\$|[$€£]|\b(BRL|USD|EUR|GBP)\b`)
+	currencyCode    = regexp.MustCompile(`^[A-Z]{3}$`)
+	// Portuguese month names, abbreviated or in full, mapped to the English
+	// abbreviation Go reads
+	ptMonths = regexp.MustCompile(`(?i)\b(jan|fev|mar|abr|mai|jun|jul|ago|set|out|nov|dez)[a-zç]*\.?`)
+)
+
+var ptMonthNames = map[string]string{
+	"jan": "Jan",
+	"fev": "Feb",
+	"mar": "Mar",
+	"abr": "Apr",
+	"mai": "May",
+	"jun": "Jun",
+	"jul": "Jul",
+	"ago": "Aug",
+	"set": "Sep",
+	"out": "Oct",
+	"nov": "Nov",
+	"dez": "Dec",
+}
+
+var symbolCurrencies = map[string]string{
+	"R$":  "BRL",
+	"US$": "USD",
+	"$":   "USD",
+	"€":   "EUR",
+	"£":   "GBP",
+}
+
+// CurrencyCode upper-cases an ISO 4217 code, failing on anything else.
+func CurrencyCode(s string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+
+	if !currencyCode.MatchString(code) {
+		return "", fmt.Errorf("invalid currency code %q", s)
+	}
+
+	return code, nil
+}
+
+func parseDate(s string, layouts []string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	named := ptMonths.ReplaceAllStringFunc(s, func(m string) string {
+		return ptMonthNames[strings.ToLower(m[:3])]
+	})
+
+	var err error
+
+	for _, l := range layouts {
+		var d time.Time
+
+		value := s
+
+		if strings.Contains(l, "Jan") {
+			value = named
+		}
+
+		if d, err = time.Parse(l, value); err == nil {
+			if !strings.Contains(l, "06") {
+				d = withYear(d, time.Now())
+			}
+
+			return d, nil
+		}
+	}
+
+	// spreadsheets store dates as days since 1899-12-30
+	if serial, convErr := strconv.ParseFloat(s, 64); convErr == nil && serial > 0 && serial < 2958466 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)), nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("no date layout for %q", s)
+	}
+
+	return time.Time{}, err
+}
+
+// withYear places dates written without a year, such as "05 fev", in the last
+// twelve months, since statements never list future days.
+func withYear(d, now time.Time) time.Time {
+	d = d.AddDate(now.Year()-d.Year(), 0, 0)
+
+	if d.After(now.AddDate(0, 1, 0)) {
+		d = d.AddDate(-1, 0, 0)
+	}
+
+	return d
+}
+
+// parseAmount reads values such as "12.90", "1.234,56", "R$ -3,10", "(5.00)"
+// or "7,50-". An empty decimalSeparator guesses it from the last separator in
+// the value. A currency symbol in the value wins over the given currency.
+func parseAmount(s, decimalSeparator, currency string) (models.Money, error) {
+	if symbol := currencySymbols.FindString(s); symbol != "" {
+		if c, ok := symbolCurrencies[strings.ToUpper(symbol)]; ok {
+			currency = c
+		} else {
+			currency = strings.ToUpper(symbol)
+		}
+	}
+
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	s = currencySymbols.ReplaceAllString(s, "")
+	s = strings.NewReplacer(" ", "", "\u00a0", "").Replace(s)
+
+	negative := false
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	if strings.HasSuffix(s, "-") {
+		negative = !negative
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			decimalSeparator = ","
+		}
+	}
+
+	if decimalSeparator == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	value, err := models.ParseMoney(s, currency)
+
+	if err != nil {
+		return value, err
+	}
+
+	if negative {
+		value = value.Neg()
+	}
+
+	return value, nil
+}
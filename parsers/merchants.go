@@ -0,0 +1,118 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// payment processors prefix the merchant name with their own code
+	processorPrefix = regexp.MustCompile(`^(PAG|PAGSEGURO|PG|MP|MERCADOPAGO|EC|SUMUP|ZP|SQ|PAYPAL|STONE|CIELO|GETNET|IZ|PICPAY)\*`)
+	spaces          = regexp.MustCompile(`\s+`)
+	star            = regexp.MustCompile(`\s*\*\s*`)
+	// card networks pad the merchant name to a fixed width before the city
+	padding = regexp.MustCompile(`(\S)\s{2,}\S`)
+	// trailing ids such as "#0012", "BR3401" or "004512"; a plain 4 digit
+	// number is more often part of the name, as in "LOJA 2024"
+	trailingId = regexp.MustCompile(`^(#\d+|[A-Z]{1,3}\d{4,}|\d{6,})$`)
+)
+
+// countryCodes close the location card networks append to titles.
+var countryCodes = []string{"BR", "BRA"}
+
+// cities are only stripped when a country code follows them, so merchants
+// named after a place, such as "BAR SANTOS", keep their name.
+var cities = []string{
+	"SAO PAULO", "RIO DE JANEIRO", "BELO HORIZONTE", "CURITIBA", "PORTO ALEGRE", "BRASILIA", "SALVADOR",
+	"RECIFE", "FORTALEZA", "CAMPINAS", "GOIANIA", "FLORIANOPOLIS", "MANAUS", "BELEM", "VITORIA", "OSASCO",
+	"GUARULHOS", "SANTOS", "NITEROI",
+}
+
+// FoldTitle puts a title in upper case with single spaces and no spaces
+// around "*", as every normalized title is. Rule patterns go through it too.
+func FoldTitle(s string) string {
+	return star.ReplaceAllString(strings.ToUpper(spaces.ReplaceAllString(s, " ")), "*")
+}
+
+// NormalizeMerchant folds the ways a merchant shows up in statements into one
+// title: upper case, single spaces, no spaces around "*", no payment
+// processor prefix and no location or terminal id left by card networks.
+// "PAG*Padaria Sao Joao SAO PAULO BR" becomes "PADARIA SAO JOAO".
+func NormalizeMerchant(title string) string {
+	title = star.ReplaceAllString(strings.TrimSpace(title), "*")
+
+	// "PADARIA SAO JOAO      SAO PAULO    BR": the location follows the padding,
+	// while "UBER  TRIP" is only spaced out
+	for _, loc := range padding.FindAllStringSubmatchIndex(title, -1) {
+		if isLocation(FoldTitle(title[loc[1]-1:])) {
+			title = title[:loc[3]]
+			break
+		}
+	}
+
+	s := processorPrefix.ReplaceAllString(strings.TrimSpace(FoldTitle(title)), "")
+
+	for {
+		trimmed := trimLocation(s)
+
+		if words := strings.Fields(trimmed); len(words) > 1 && trailingId.MatchString(words[len(words)-1]) {
+			trimmed = strings.Join(words[:len(words)-1], " ")
+		}
+
+		trimmed = strings.TrimRight(trimmed, " -*")
+
+		if trimmed == s || trimmed == "" {
+			break
+		}
+
+		s = trimmed
+	}
+
+	// "IFOOD *IFOOD" names the merchant twice
+	if before, after, ok := strings.Cut(s, "*"); ok && before == after {
+		s = before
+	}
+
+	return s
+}
+
+// trimLocation drops a trailing "<city> <country>" pair.
+func trimLocation(s string) string {
+	for _, code := range countryCodes {
+		rest, ok := strings.CutSuffix(s, " "+code)
+
+		if !ok {
+			continue
+		}
+
+		for _, city := range cities {
+			if name, ok := strings.CutSuffix(rest, " "+city); ok && strings.TrimSpace(name) != "" {
+				return name
+			}
+		}
+	}
+
+	return s
+}
+
+// isLocation reports whether s is a known city, a country code or both.
+func isLocation(s string) bool {
+	for _, code := range countryCodes {
+		if s == code {
+			return true
+		}
+
+		if rest, ok := strings.CutSuffix(s, " "+code); ok {
+			s = rest
+			break
+		}
+	}
+
+	for _, city := range cities {
+		if s == city {
+			return true
+		}
+	}
+
+	return false
+}
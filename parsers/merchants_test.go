@@ -0,0 +1,29 @@
+package parsers
+
+import "testing"
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"UBER  TRIP", "UBER TRIP"},
+		{"UBER  EATS", "UBER EATS"},
+		{"UBER *TRIP", "UBER*TRIP"},
+		{"PADARIA SAO JOAO      SAO PAULO    BR", "PADARIA SAO JOAO"},
+		{"PADARIA SAO JOAO      SAO PAULO", "PADARIA SAO JOAO"},
+		{"MERCADO CENTRAL       BR", "MERCADO CENTRAL"},
+		{"PAG*Padaria Sao Joao SAO PAULO BR", "PADARIA SAO JOAO"},
+		{"Bar Santos", "BAR SANTOS"},
+		{"Loja 2024", "LOJA 2024"},
+		{"MERCADO 004512", "MERCADO"},
+		{"IFOOD *IFOOD", "IFOOD"},
+		{"  posto   ipiranga  ", "POSTO IPIRANGA"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeMerchant(tt.title); got != tt.want {
+			t.Errorf("NormalizeMerchant(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
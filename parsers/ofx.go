@@ -0,0 +1,156 @@
+package parsers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"csv_extractor/models"
+)
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?i)<STMTTRN>`)
+	ofxBlockEnd    = regexp.MustCompile(`(?i)</STMTTRN>|</BANKTRANLIST>`)
+	ofxField       = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxAccount     = regexp.MustCompile(`(?i)<ACCTID>([^<\r\n]*)`)
+	ofxCurrency    = regexp.MustCompile(`(?i)<CURDEF>([^<\r\n]*)`)
+)
+
+// Ofx reads OFX and QFX statements, both the SGML (1.x) and XML (2.x)
+// flavours. Amounts are negative for spending, so they are flipped. The whole
+// file is read first since the account id precedes the transaction list.
+type Ofx struct{}
+
+func (Ofx) Format() string {
+	return "ofx"
+}
+
+func (Ofx) Detect(head []byte) bool {
+	upper := bytes.ToUpper(head)
+
+	return bytes.Contains(upper, []byte("OFXHEADER")) || bytes.Contains(upper, []byte("<OFX>"))
+}
+
+func (Ofx) Parse(r io.Reader, sink Sink) error {
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	text := string(data)
+
+	var account string
+
+	if m := ofxAccount.FindStringSubmatch(text); m != nil {
+		account = strings.TrimSpace(m[1])
+	}
+
+	currency := models.DefaultCurrency
+
+	if m := ofxCurrency.FindStringSubmatch(text); m != nil {
+		if c, err := CurrencyCode(m[1]); err == nil {
+			currency = c
+		}
+	}
+
+	// SGML files never close STMTTRN, so each block runs until the next one
+	starts := ofxTransaction.FindAllStringIndex(text, -1)
+
+	for i, loc := range starts {
+		end := len(text)
+
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+
+		block := text[loc[1]:end]
+
+		if e := ofxBlockEnd.FindStringIndex(block); e != nil {
+			block = block[:e[0]]
+		}
+
+		fields := make(map[string]string)
+
+		for _, f := range ofxField.FindAllStringSubmatch(block, -1) {
+			// SGML values escape markup characters, as in "M&amp;M"
+			fields[strings.ToUpper(f[1])] = html.UnescapeString(strings.TrimSpace(f[2]))
+		}
+
+		t, err := ofxToTransaction(fields, account, currency)
+
+		if err != nil {
+			err = sink.Reject(models.LineError{
+				Line:   strings.Count(text[:loc[0]], "\n") + 1,
+				Raw:    strings.TrimSpace(text[loc[0]:loc[1]] + block),
+				Reason: err.Error(),
+			})
+		} else {
+			err = sink.Add(t)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ofxToTransaction(fields map[string]string, account, currency string) (models.Transaction, error) {
+	var t models.Transaction
+
+	date, err := parseOfxDate(fields["DTPOSTED"])
+
+	if err != nil {
+		return t, fmt.Errorf("date conversion error: %w", err)
+	}
+
+	value, err := parseAmount(fields["TRNAMT"], "", currency)
+
+	if err != nil {
+		return t, fmt.Errorf("value conversion error: %w", err)
+	}
+
+	raw := fields["NAME"]
+
+	if raw == "" {
+		raw = fields["MEMO"]
+	}
+
+	if raw == "" {
+		return t, errors.New("transaction has no name or memo")
+	}
+
+	t = models.Transaction{
+		Date:     date,
+		RawTitle: raw,
+		Title:    raw,
+		Value:    value.Neg(),
+	}
+
+	setInstallment(&t)
+	classify(&t)
+	t.Title = NormalizeMerchant(t.Title)
+
+	// FITID is only unique within one account
+	if fitid := fields["FITID"]; fitid != "" {
+		t.ExternalId = account + ":" + fitid
+	}
+
+	return t, nil
+}
+
+// parseOfxDate reads YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]], keeping only the day.
+func parseOfxDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid ofx date %q", s)
+	}
+
+	return time.Parse("20060102", s[:8])
+}
@@ -0,0 +1,88 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"csv_extractor/models"
+)
+
+// Sink receives lines as they are parsed, so statements never have to be
+// held in memory as a whole. Returning an error stops the parser.
+type Sink interface {
+	Add(t models.Transaction) error
+	Reject(e models.LineError) error
+}
+
+// StatementParser turns one bank statement layout into transactions.
+type StatementParser interface {
+	Format() string
+	// Detect reports whether the beginning of a file looks like this layout.
+	Detect(head []byte) bool
+	// Parse hands every transaction it reads and every line it rejects to
+	// the sink. An error means the file could not be read at all.
+	Parse(r io.Reader, sink Sink) error
+}
+
+// Delimited parsers read delimiter separated text and can be told which
+// delimiter to use.
+type Delimited interface {
+	WithDelimiter(comma rune) StatementParser
+}
+
+var registry []StatementParser
+
+// Register adds a parser. Detection tries parsers in registration order, so
+// the most permissive ones must be registered last.
+func Register(p StatementParser) {
+	registry = append(registry, p)
+}
+
+func Formats() []string {
+	var names []string
+
+	for _, p := range registry {
+		names = append(names, p.Format())
+	}
+
+	return names
+}
+
+func Get(format string) (StatementParser, error) {
+	for _, p := range registry {
+		if p.Format() == format {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown statement format %q", format)
+}
+
+// Detect finds the layout of a file from its first bytes. A non-zero comma
+// forces the delimiter of delimited layouts; otherwise it is sniffed when no
+// layout matches with its usual one.
+func Detect(head []byte, comma rune) (StatementParser, error) {
+	if comma == 0 {
+		for _, p := range registry {
+			if p.Detect(head) {
+				return p, nil
+			}
+		}
+
+		// known layouts re-saved with another delimiter, as spreadsheets often do
+		comma = sniffComma(head)
+	}
+
+	for _, p := range registry {
+		if d, ok := p.(Delimited); ok {
+			p = d.WithDelimiter(comma)
+		}
+
+		if p.Detect(head) {
+			return p, nil
+		}
+	}
+
+	return nil, errors.New("unable to detect statement format")
+}
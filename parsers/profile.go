@@ -0,0 +1,369 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"csv_extractor/models"
+)
+
+// RowReader is satisfied by *csv.Reader and by any other tabular source.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// ColumnProfile describes a tabular statement: where each field lives and how
+// its values are written.
+type ColumnProfile struct {
+	Name string
+	// Header holds the lower-cased column names used to recognise the layout.
+	Header       []string
+	Comma        rune
+	SkipRows     int
+	DateColumn   int
+	TitleColumn  int
+	AmountColumn int
+	// SplitAmount reads spending from DebitColumn and income from CreditColumn
+	// instead of a single signed AmountColumn.
+	SplitAmount      bool
+	DebitColumn      int
+	CreditColumn     int
+	DateLayouts      []string
+	DecimalSeparator string
+	// Negate flips the sign of amounts for statements where spending is negative.
+	Negate       bool
+	IgnoreTitles []string
+	TrimSuffixes []string
+	// Currency of the account, used when amounts carry no symbol.
+	Currency string
+	// OriginalAmountColumn holds purchases made abroad in OriginalCurrency,
+	// before conversion. It is only read when OriginalCurrency is set.
+	OriginalAmountColumn int
+	OriginalCurrency     string
+}
+
+func NewCsvReader(r io.Reader, comma rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	return reader
+}
+
+func (p *ColumnProfile) Format() string {
+	return p.Name
+}
+
+// WithDelimiter returns a copy of the profile reading another delimiter.
+func (p *ColumnProfile) WithDelimiter(comma rune) StatementParser {
+	c := *p
+	c.Comma = comma
+
+	return &c
+}
+
+func (p *ColumnProfile) currency() string {
+	if p.Currency == "" {
+		return models.DefaultCurrency
+	}
+
+	return p.Currency
+}
+
+func (p *ColumnProfile) comma() rune {
+	if p.Comma == 0 {
+		return ','
+	}
+
+	return p.Comma
+}
+
+func (p *ColumnProfile) Detect(head []byte) bool {
+	if len(p.Header) == 0 {
+		return false
+	}
+
+	fields, err := firstRow(head, p.comma())
+
+	if err != nil || len(fields) < len(p.Header) {
+		return false
+	}
+
+	return p.MatchHeader(fields)
+}
+
+func (p *ColumnProfile) MatchHeader(fields []string) bool {
+	if len(p.Header) == 0 || len(fields) < len(p.Header) {
+		return false
+	}
+
+	for i, h := range p.Header {
+		if normalizeHeader(fields[i]) != h {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *ColumnProfile) Parse(r io.Reader, sink Sink) error {
+	return p.ParseRows(NewCsvReader(r, p.comma()), sink)
+}
+
+// ParseRows maps every row it can and reports the others. Only a failure to
+// read the source at all is returned as an error.
+func (p *ColumnProfile) ParseRows(rows RowReader, sink Sink) error {
+	count := 0
+
+	for {
+		row, err := rows.Read()
+		count++
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			var pe *csv.ParseError
+
+			// the csv reader resumes on the next record after a parse error
+			if errors.As(err, &pe) {
+				err = sink.Reject(models.LineError{
+					Line:   pe.Line,
+					Reason: "line reading error: " + pe.Err.Error(),
+				})
+
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			return fmt.Errorf("line reading error: %w", err)
+		}
+
+		if count <= p.SkipRows {
+			continue
+		}
+
+		t, ok, err := p.Transaction(row)
+
+		if err != nil {
+			err = sink.Reject(models.LineError{
+				Line:   rowLine(rows, count),
+				Raw:    strings.Join(row, string(p.comma())),
+				Reason: err.Error(),
+			})
+		} else if ok {
+			err = sink.Add(t)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rowLine prefers the file line of the last row, which differs from the row
+// count when quoted fields span several lines.
+func rowLine(rows RowReader, count int) int {
+	if fp, ok := rows.(interface{ FieldPos(int) (int, int) }); ok {
+		line, _ := fp.FieldPos(0)
+		return line
+	}
+
+	return count
+}
+
+// Transaction maps a single row. ok is false for rows the profile ignores.
+func (p *ColumnProfile) Transaction(row []string) (t models.Transaction, ok bool, err error) {
+	for _, c := range p.columns() {
+		if c < 0 || c >= len(row) {
+			return t, false, fmt.Errorf("missing column %d", c)
+		}
+	}
+
+	raw := strings.TrimSpace(row[p.TitleColumn])
+	title := p.CleanTitle(raw)
+
+	for _, ignored := range p.IgnoreTitles {
+		if strings.EqualFold(title, ignored) {
+			return t, false, nil
+		}
+	}
+
+	date, err := parseDate(row[p.DateColumn], p.DateLayouts)
+
+	if err != nil {
+		return t, false, fmt.Errorf("date conversion error: %w", err)
+	}
+
+	value, err := p.amount(row)
+
+	if err != nil {
+		return t, false, fmt.Errorf("value conversion error: %w", err)
+	}
+
+	original, err := p.originalAmount(row)
+
+	if err != nil {
+		return t, false, fmt.Errorf("original value conversion error: %w", err)
+	}
+
+	if p.Negate {
+		value = value.Neg()
+
+		if original != nil {
+			*original = original.Neg()
+		}
+	}
+
+	t = models.Transaction{
+		Date:          date,
+		RawTitle:      raw,
+		Title:         title,
+		Value:         value,
+		OriginalValue: original,
+	}
+
+	setInstallment(&t)
+	classify(&t)
+	t.Title = NormalizeMerchant(t.Title)
+
+	return t, true, nil
+}
+
+func (p *ColumnProfile) columns() []int {
+	if p.SplitAmount {
+		return []int{p.DateColumn, p.TitleColumn, p.DebitColumn, p.CreditColumn}
+	}
+
+	return []int{p.DateColumn, p.TitleColumn, p.AmountColumn}
+}
+
+func (p *ColumnProfile) amount(row []string) (models.Money, error) {
+	if !p.SplitAmount {
+		return parseAmount(row[p.AmountColumn], p.DecimalSeparator, p.Currency)
+	}
+
+	var value models.Money
+
+	// only one of the two cells is usually filled
+	if s := strings.TrimSpace(row[p.DebitColumn]); s != "" {
+		debit, err := parseAmount(s, p.DecimalSeparator, p.Currency)
+
+		if err != nil {
+			return value, err
+		}
+
+		value = value.Add(debit.Abs())
+	}
+
+	if s := strings.TrimSpace(row[p.CreditColumn]); s != "" {
+		credit, err := parseAmount(s, p.DecimalSeparator, p.Currency)
+
+		if err != nil {
+			return value, err
+		}
+
+		value = value.Sub(credit.Abs())
+	}
+
+	if value.Currency == "" {
+		value.Currency = p.currency()
+	}
+
+	return value, nil
+}
+
+// originalAmount reads the amount before conversion, nil when the row has
+// none, as purchases in the account currency leave it empty or zero.
+func (p *ColumnProfile) originalAmount(row []string) (*models.Money, error) {
+	if p.OriginalCurrency == "" || p.OriginalAmountColumn >= len(row) {
+		return nil, nil
+	}
+
+	s := strings.TrimSpace(row[p.OriginalAmountColumn])
+
+	if s == "" {
+		return nil, nil
+	}
+
+	original, err := parseAmount(s, p.DecimalSeparator, p.OriginalCurrency)
+
+	if err != nil || original.IsZero() {
+		return nil, err
+	}
+
+	return &original, nil
+}
+
+func (p *ColumnProfile) CleanTitle(s string) string {
+	for _, suffix := range p.TrimSuffixes {
+		if idx := strings.Index(s, suffix); idx >= 0 {
+			s = s[:idx]
+		}
+	}
+
+	return strings.TrimSpace(s)
+}
+
+func normalizeHeader(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(s, "\ufeff")))
+}
+
+func firstRow(head []byte, comma rune) ([]string, error) {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+
+	return NewCsvReader(bytes.NewReader(line), comma).Read()
+}
+
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// sniffComma picks the delimiter that splits the first line into the most
+// fields, ignoring delimiters inside quotes.
+func sniffComma(head []byte) rune {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+
+	best, bestCount := ',', 0
+
+	for _, d := range delimiters {
+		count, quoted := 0, false
+
+		for _, r := range string(line) {
+			switch {
+			case r == '"':
+				quoted = !quoted
+			case r == d && !quoted:
+				count++
+			}
+		}
+
+		if count > bestCount {
+			best, bestCount = d, count
+		}
+	}
+
+	return best
+}
+
+// peekHead returns the first bytes of r without consuming them.
+func peekHead(r io.Reader) (*bufio.Reader, []byte) {
+	br, ok := r.(*bufio.Reader)
+
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	head, _ := br.Peek(4096)
+
+	return br, head
+}
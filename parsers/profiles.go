@@ -0,0 +1,209 @@
+package parsers
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"time"
+)
+
+const brDate = "02/01/2006"
+
+var NubankCard = &ColumnProfile{
+	Name:             "nubank-card",
+	Header:           []string{"date", "title", "amount"},
+	Comma:            ',',
+	SkipRows:         1,
+	DateColumn:       0,
+	TitleColumn:      1,
+	AmountColumn:     2,
+	DateLayouts:      []string{time.DateOnly},
+	DecimalSeparator: ".",
+	TrimSuffixes:     []string{" - Parcela", " - NuPay"},
+}
+
+var NubankAccount = &ColumnProfile{
+	Name:             "nubank-account",
+	Header:           []string{"data", "valor", "identificador", "descrição"},
+	Comma:            ',',
+	SkipRows:         1,
+	DateColumn:       0,
+	TitleColumn:      3,
+	AmountColumn:     1,
+	DateLayouts:      []string{brDate},
+	DecimalSeparator: ".",
+	Negate:           true,
+}
+
+var InterCard = &ColumnProfile{
+	Name:             "inter",
+	Header:           []string{"data", "lançamento", "categoria", "tipo", "valor"},
+	Comma:            ',',
+	SkipRows:         1,
+	DateColumn:       0,
+	TitleColumn:      1,
+	AmountColumn:     4,
+	DateLayouts:      []string{brDate},
+	DecimalSeparator: ",",
+}
+
+var Itau = &ColumnProfile{
+	Name:             "itau",
+	Header:           []string{"data", "lançamento", "valor"},
+	Comma:            ';',
+	SkipRows:         1,
+	DateColumn:       0,
+	TitleColumn:      1,
+	AmountColumn:     2,
+	DateLayouts:      []string{brDate},
+	DecimalSeparator: ",",
+	Negate:           true,
+}
+
+var C6Card = &ColumnProfile{
+	Name:             "c6",
+	Header:           []string{"data de compra", "nome no cartão", "final do cartão", "categoria", "descrição", "parcela"},
+	Comma:            ';',
+	SkipRows:         1,
+	DateColumn:       0,
+	TitleColumn:      4,
+	AmountColumn:     8,
+	DateLayouts:      []string{brDate},
+	DecimalSeparator: ".",
+	// "Valor (em US$)", converted into "Valor (em R$)" by the card
+	OriginalAmountColumn: 6,
+	OriginalCurrency:     "USD",
+}
+
+var (
+	genericDateHeaders   = []string{"date", "data", "data lançamento", "data de compra"}
+	genericTitleHeaders  = []string{"title", "description", "descrição", "descricao", "lançamento", "histórico", "historico"}
+	genericAmountHeaders = []string{"amount", "value", "valor", "valor (r$)", "valor (em r$)"}
+)
+
+// Generic recognises any statement whose header names a date, a description
+// and an amount column. The delimiter is sniffed unless Comma is set.
+type Generic struct {
+	Comma rune
+}
+
+func (Generic) Format() string {
+	return "generic"
+}
+
+// Profile builds a column profile from a header row.
+func (Generic) Profile(header []string, comma rune) (*ColumnProfile, error) {
+	p := &ColumnProfile{
+		Name:         "generic",
+		Comma:        comma,
+		DateColumn:   -1,
+		TitleColumn:  -1,
+		AmountColumn: -1,
+		DateLayouts:  Locales["pt-BR"].DateLayouts,
+	}
+
+	for i, h := range header {
+		h = normalizeHeader(h)
+
+		switch {
+		case p.DateColumn < 0 && slices.Contains(genericDateHeaders, h):
+			p.DateColumn = i
+		case p.TitleColumn < 0 && slices.Contains(genericTitleHeaders, h):
+			p.TitleColumn = i
+		case p.AmountColumn < 0 && slices.Contains(genericAmountHeaders, h):
+			p.AmountColumn = i
+		}
+	}
+
+	if p.DateColumn < 0 || p.TitleColumn < 0 || p.AmountColumn < 0 {
+		return nil, errors.New("header has no date, description or amount column")
+	}
+
+	return p, nil
+}
+
+func (g Generic) WithDelimiter(comma rune) StatementParser {
+	return Generic{Comma: comma}
+}
+
+func (g Generic) comma(head []byte) rune {
+	if g.Comma != 0 {
+		return g.Comma
+	}
+
+	return sniffComma(head)
+}
+
+func (g Generic) Detect(head []byte) bool {
+	comma := g.comma(head)
+
+	header, err := firstRow(head, comma)
+
+	if err != nil {
+		return false
+	}
+
+	_, err = g.Profile(header, comma)
+
+	return err == nil
+}
+
+func (g Generic) MatchHeader(fields []string) bool {
+	_, err := g.Profile(fields, ',')
+
+	return err == nil
+}
+
+func (g Generic) Parse(r io.Reader, sink Sink) error {
+	br, head := peekHead(r)
+
+	return g.ParseRows(NewCsvReader(br, g.comma(head)), sink)
+}
+
+// ParseRows reads the header row first to find where each column lives.
+func (g Generic) ParseRows(rows RowReader, sink Sink) error {
+	header, err := rows.Read()
+
+	if err != nil {
+		return err
+	}
+
+	p, err := g.Profile(header, ',')
+
+	if err != nil {
+		return err
+	}
+
+	return p.ParseRows(&offsetRows{RowReader: rows, offset: 1}, sink)
+}
+
+// offsetRows keeps row counts aligned with the file once a header was read.
+type offsetRows struct {
+	RowReader
+	offset int
+	count  int
+}
+
+func (o *offsetRows) Read() ([]string, error) {
+	o.count++
+
+	return o.RowReader.Read()
+}
+
+func (o *offsetRows) FieldPos(field int) (int, int) {
+	if fp, ok := o.RowReader.(interface{ FieldPos(int) (int, int) }); ok {
+		return fp.FieldPos(field)
+	}
+
+	return o.count + o.offset, 0
+}
+
+func init() {
+	Register(Ofx{})
+	Register(NubankCard)
+	Register(NubankAccount)
+	Register(InterCard)
+	Register(Itau)
+	Register(C6Card)
+	Register(Generic{})
+}
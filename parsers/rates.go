@@ -0,0 +1,97 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv_extractor/models"
+)
+
+var rateDateLayouts = []string{time.DateOnly, brDate}
+
+// ParseExchangeRates reads "date,from,to,rate" rows, or "date,currency,rate"
+// rows quoting against BRL. A header row and either delimiter are accepted.
+func ParseExchangeRates(r io.Reader) ([]models.ExchangeRate, []models.LineError, error) {
+	br, head := peekHead(r)
+	rows := NewCsvReader(br, sniffComma(head))
+
+	var rates []models.ExchangeRate
+	var rejected []models.LineError
+
+	for line := 1; ; line++ {
+		row, err := rows.Read()
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return rates, rejected, nil
+			}
+
+			return nil, nil, fmt.Errorf("line reading error: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(normalizeHeader(row[0]), "date") {
+			continue
+		}
+
+		rate, err := exchangeRate(row)
+
+		if err != nil {
+			rejected = append(rejected, models.LineError{
+				Line:   line,
+				Raw:    strings.Join(row, ","),
+				Reason: err.Error(),
+			})
+
+			continue
+		}
+
+		rates = append(rates, rate)
+	}
+}
+
+func exchangeRate(row []string) (models.ExchangeRate, error) {
+	var r models.ExchangeRate
+	var err error
+
+	if len(row) < 3 {
+		return r, errors.New("expected date, currency and rate columns")
+	}
+
+	r.To = models.DefaultCurrency
+
+	if r.Date, err = parseDate(row[0], rateDateLayouts); err != nil {
+		return r, fmt.Errorf("date conversion error: %w", err)
+	}
+
+	if r.From, err = CurrencyCode(row[1]); err != nil {
+		return r, err
+	}
+
+	value := row[2]
+
+	if len(row) > 3 {
+		if r.To, err = CurrencyCode(row[2]); err != nil {
+			return r, err
+		}
+
+		value = row[3]
+	}
+
+	if r.From == r.To {
+		return r, errors.New("rate converts a currency into itself")
+	}
+
+	if r.Rate, err = strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", "."), 64); err != nil {
+		return r, fmt.Errorf("rate conversion error: %w", err)
+	}
+
+	if r.Rate <= 0 {
+		return r, errors.New("rate must be positive")
+	}
+
+	return r, nil
+}
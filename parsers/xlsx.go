@@ -0,0 +1,337 @@
+package parsers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RowParser maps rows that were already split into cells, whatever file
+// they came from.
+type RowParser interface {
+	ParseRows(rows RowReader, sink Sink) error
+}
+
+// HeaderMatcher recognises a layout from its header row.
+type HeaderMatcher interface {
+	MatchHeader(fields []string) bool
+}
+
+func IsXlsx(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04"))
+}
+
+// Xlsx reads one sheet of a spreadsheet and hands its rows to a column
+// profile, detected from the header row when none is given. Unlike csv, the
+// zip container has to be read into memory before any row is available.
+type Xlsx struct {
+	Sheet string
+	Rows  RowParser
+}
+
+func NewXlsx(sheet string, p StatementParser) (*Xlsx, error) {
+	if p == nil {
+		return &Xlsx{Sheet: sheet}, nil
+	}
+
+	rp, ok := p.(RowParser)
+
+	if !ok {
+		return nil, fmt.Errorf("format %q can't read spreadsheets", p.Format())
+	}
+
+	return &Xlsx{Sheet: sheet, Rows: forSheet(rp)}, nil
+}
+
+// forSheet adapts a row parser to spreadsheet cells. Numeric cells always use
+// a dot, so column profiles guess the separator of text cells instead of
+// applying their own to every cell.
+func forSheet(rp RowParser) RowParser {
+	if cp, ok := rp.(*ColumnProfile); ok {
+		c := *cp
+		c.DecimalSeparator = ""
+		return &c
+	}
+
+	return rp
+}
+
+func (x *Xlsx) Format() string {
+	return "xlsx"
+}
+
+func (x *Xlsx) Detect(head []byte) bool {
+	return IsXlsx(head)
+}
+
+func (x *Xlsx) Parse(r io.Reader, sink Sink) error {
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := ReadXlsx(data, x.Sheet)
+
+	if err != nil {
+		return err
+	}
+
+	rp := x.Rows
+
+	if rp == nil {
+		if len(rows) == 0 {
+			return errors.New("sheet is empty")
+		}
+
+		if rp, err = DetectRows(rows[0]); err != nil {
+			return err
+		}
+
+		rp = forSheet(rp)
+	}
+
+	return rp.ParseRows(&sliceRows{rows: rows}, sink)
+}
+
+// DetectRows picks the registered layout whose header matches.
+func DetectRows(header []string) (RowParser, error) {
+	for _, p := range registry {
+		m, ok := p.(HeaderMatcher)
+
+		if !ok || !m.MatchHeader(header) {
+			continue
+		}
+
+		if rp, ok := p.(RowParser); ok {
+			return rp, nil
+		}
+	}
+
+	return nil, errors.New("unable to detect statement format")
+}
+
+type sliceRows struct {
+	rows [][]string
+	next int
+}
+
+func (s *sliceRows) Read() ([]string, error) {
+	if s.next >= len(s.rows) {
+		return nil, io.EOF
+	}
+
+	row := s.rows[s.next]
+	s.next++
+
+	return row, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		Rid  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXlsx returns the cells of a sheet as text. An empty sheet name selects
+// the first sheet.
+func ReadXlsx(data []byte, sheet string) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	var wb xlsxWorkbook
+
+	if err := readZipXml(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+
+	var rels xlsxRelationships
+
+	if err := readZipXml(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	var rid string
+
+	for _, s := range wb.Sheets {
+		if sheet == "" || s.Name == sheet {
+			rid = s.Rid
+			break
+		}
+	}
+
+	if rid == "" {
+		return nil, fmt.Errorf("sheet %q not found", sheet)
+	}
+
+	var target string
+
+	for _, r := range rels.Relationships {
+		if r.Id == rid {
+			target = r.Target
+		}
+	}
+
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared xlsxSharedStrings
+
+	// workbooks without text cells have no shared strings part
+	if err := readZipXml(zr, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, errZipPartMissing) {
+		return nil, err
+	}
+
+	strs := make([]string, len(shared.Items))
+
+	for i, si := range shared.Items {
+		strs[i] = si.T
+
+		for _, r := range si.Runs {
+			strs[i] += r.T
+		}
+	}
+
+	var ws xlsxSheet
+
+	if err := readZipXml(zr, target, &ws); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(ws.Rows))
+
+	for _, r := range ws.Rows {
+		var row []string
+
+		for i, c := range r.Cells {
+			col := i
+
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+
+			if col < 0 || col > maxColumn {
+				return nil, fmt.Errorf("cell %q: invalid cell reference", c.Ref)
+			}
+
+			for len(row) <= col {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+
+				if err != nil || idx < 0 || idx >= len(strs) {
+					return nil, fmt.Errorf("cell %s: invalid shared string", c.Ref)
+				}
+
+				row[col] = strs[idx]
+			case "inlineStr":
+				row[col] = c.Inline
+			case "", "n":
+				row[col] = xlsxNumber(c.Value)
+			default:
+				row[col] = c.Value
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// xlsxNumber writes a numeric cell in its shortest form, as spreadsheets may
+// store 12.9 as "12.9000000000000004".
+func xlsxNumber(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+
+	if err != nil {
+		return v
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var errZipPartMissing = errors.New("xlsx part missing")
+
+func readZipXml(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", errZipPartMissing, name)
+	}
+
+	defer f.Close()
+
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("invalid xlsx part %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// maxColumn is the last column a worksheet may have, XFD.
+const maxColumn = 16383
+
+// columnIndex turns a cell reference such as "AB12" into a zero based column.
+// It returns -1 when the reference has no column letters.
+func columnIndex(ref string) int {
+	col := 0
+
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+
+		col = col*26 + int(r-'A'+1)
+
+		// stops before long references overflow
+		if col > maxColumn+1 {
+			return maxColumn + 1
+		}
+	}
+
+	return col - 1
+}
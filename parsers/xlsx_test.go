@@ -0,0 +1,117 @@
+package parsers
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"csv_extractor/models"
+)
+
+type sliceSink struct {
+	added    []models.Transaction
+	rejected []models.LineError
+}
+
+func (s *sliceSink) Add(t models.Transaction) error {
+	s.added = append(s.added, t)
+	return nil
+}
+
+func (s *sliceSink) Reject(e models.LineError) error {
+	s.rejected = append(s.rejected, e)
+	return nil
+}
+
+// buildXlsx writes a one sheet workbook whose rows hold inline text cells,
+// except for cells prefixed with "=" which are written as numbers.
+func buildXlsx(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+
+	var sheet strings.Builder
+
+	for _, row := range rows {
+		sheet.WriteString("<row>")
+
+		for i, v := range row {
+			ref := string(rune('A'+i)) + "1"
+
+			if n, ok := strings.CutPrefix(v, "="); ok {
+				sheet.WriteString(`<c r="` + ref + `"><v>` + n + `</v></c>`)
+			} else {
+				sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t>` + v + `</t></is></c>`)
+			}
+		}
+
+		sheet.WriteString("</row>")
+	}
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Extrato" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + sheet.String() + `</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range parts {
+		w, err := zw.Create(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestXlsxDetectedProfileReadsNumericCells(t *testing.T) {
+	data := buildXlsx(t, [][]string{
+		{"Data", "Lançamento", "Categoria", "Tipo", "Valor"},
+		{"05/01/2024", "PADARIA", "Alimentação", "Compra", "=12.9"},
+		{"06/01/2024", "MERCADO", "Alimentação", "Compra", "1.234,56"},
+	})
+
+	x, err := NewXlsx("", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sink sliceSink
+
+	if err := x.Parse(bytes.NewReader(data), &sink); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.rejected) > 0 {
+		t.Fatalf("rejected rows: %v", sink.rejected)
+	}
+
+	want := []int64{1290, 123456}
+
+	if len(sink.added) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(sink.added), len(want))
+	}
+
+	for i, cents := range want {
+		if got := sink.added[i].Value.Cents; got != cents {
+			t.Errorf("row %d: got %d cents, want %d", i, got, cents)
+		}
+	}
+
+	if InterCard.DecimalSeparator != "," {
+		t.Errorf("registered profile was changed: separator %q", InterCard.DecimalSeparator)
+	}
+}
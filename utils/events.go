@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StartEventStream prepares a Server-Sent Events response.
+func StartEventStream(w http.ResponseWriter) (http.Flusher, bool) {
+	f, ok := w.(http.Flusher)
+
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	return f, true
+}
+
+func SendEvent(w http.ResponseWriter, f http.Flusher, event string, d interface{}) error {
+	data, err := json.Marshal(d)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+
+	f.Flush()
+
+	return nil
+}
@@ -8,31 +8,39 @@ import (
 func PrintMap(m map[string]models.Expense) {
 	fmt.Printf("\n- Lista de gastos: \n")
 	for i, expense := range m {
-		fmt.Printf("    %s: %.2f \n", i, expense.Value)
+		fmt.Printf("    %s: %s \n", i, expense.Value)
 	}
 }
 
 func PrintTotal(m map[string]models.Expense) {
-	var total = 0.0
+	var total models.Money
 
 	for _, expense := range m {
-		total += expense.Value
+		total = total.Add(expense.Value)
 	}
 
-	fmt.Printf("\nTotal: %.2f \n\n", total)
+	fmt.Printf("\nTotal: %s \n\n", total)
 }
 
-func SumByCategory(m map[string]models.Expense) {
-	groups := make(map[string]float64)
+// SumByCategory prints the totals per category, rolling subcategories up to
+// their ancestor at level, 1 being the top level. Level 0 keeps each category.
+func SumByCategory(m map[string]models.Expense, tree models.CategoryTree, level int) {
+	groups := make(map[string]models.Money)
 
 	for _, expense := range m {
-		groups[expense.Category] += expense.Value
+		category := expense.Category
+
+		if c, ok := tree.RollUp(expense.CategoryId, level); ok {
+			category = c.Name
+		}
+
+		groups[category] = groups[category].Add(expense.Value)
 	}
 
 	fmt.Printf("\n- Gastos por Categoria: \n")
 
 	for category, value := range groups {
-		fmt.Printf("    %s: %.2f \n", category, value)
+		fmt.Printf("    %s: %s \n", category, value)
 	}
 
 	fmt.Printf("\n")
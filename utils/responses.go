@@ -38,6 +38,20 @@ func DataResponse(w http.ResponseWriter, m string, d interface{}) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func AcceptedResponse(w http.ResponseWriter, m string, d interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusAccepted)
+
+	resp := Message{
+		Error:   false,
+		Message: m,
+		Data:    d,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
 func ErrorResponse(w http.ResponseWriter, m string, code int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -50,3 +64,17 @@ func ErrorResponse(w http.ResponseWriter, m string, code int) {
 
 	json.NewEncoder(w).Encode(resp)
 }
+
+func ErrorDataResponse(w http.ResponseWriter, m string, code int, d interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+
+	resp := Message{
+		Error:   true,
+		Message: m,
+		Data:    d,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}